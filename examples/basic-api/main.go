@@ -3,15 +3,18 @@ package main
 
 import (
 	"context"
+	"errors"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/upnext-fng/fulcrum/security/jwt"
+	"github.com/upnext-fng/fulcrum/security/lockout"
 	"github.com/upnext-fng/fulcrum/security/middleware"
 	"github.com/upnext-fng/fulcrum/security/password"
 	"go.uber.org/fx"
+	"gorm.io/gorm"
 
 	"github.com/upnext-fng/fulcrum/configuration"
 	"github.com/upnext-fng/fulcrum/database"
@@ -32,17 +35,20 @@ type User struct {
 type APIRoutes struct {
 	db       database.DatabaseService
 	security security.SecurityService
+	lockout  lockout.Service
 	logger   observability.ObservabilityService
 }
 
 func NewAPIRoutes(
 	db database.DatabaseService,
 	security security.SecurityService,
+	lockout lockout.Service,
 	logger observability.ObservabilityService,
 ) *APIRoutes {
 	return &APIRoutes{
 		db:       db,
 		security: security,
+		lockout:  lockout,
 		logger:   logger,
 	}
 }
@@ -82,6 +88,16 @@ func (r *APIRoutes) Register(c echo.Context) error {
 		return echo.NewHTTPError(400, "Invalid request body")
 	}
 
+	// Check the password policy, reporting every violation to the form
+	err := r.security.ValidatePasswordFor(req.Password, password.PolicyContext{
+		Username: req.Username,
+		Email:    req.Email,
+	})
+	var policyErr *password.PolicyError
+	if errors.As(err, &policyErr) {
+		return c.JSON(400, policyErr)
+	}
+
 	// Hash password
 	hashedPassword, err := r.security.HashPassword(req.Password)
 	if err != nil {
@@ -121,19 +137,46 @@ func (r *APIRoutes) Login(c echo.Context) error {
 		return echo.NewHTTPError(400, "Invalid request body")
 	}
 
-	// Find user
+	// Failed attempts lock the username and client address; unknown
+	// usernames count too, so they cannot be told apart
 	var user User
-	if err := r.db.Connection().Where("username = ?", req.Username).First(&user).Error; err != nil {
-		r.logger.Logger().WithField("username", req.Username).Warn("Login attempt with invalid username")
+	var rehashed string
+	err := r.lockout.Guard(req.Username, c.RealIP(), func() error {
+		err := r.db.Connection().Where("username = ?", req.Username).First(&user).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return lockout.ErrInvalidCredentials
+		}
+		if err != nil {
+			return err
+		}
+
+		// Verify password, upgrading hashes with outdated parameters
+		rehashed, err = r.security.VerifyAndRehashPassword(user.Password, req.Password)
+		if errors.Is(err, password.ErrMismatchedPassword) || errors.Is(err, password.ErrPolicyViolation) {
+			return lockout.ErrInvalidCredentials
+		}
+		return err
+	})
+
+	var locked *lockout.LockedError
+	if errors.As(err, &locked) {
+		r.logger.Logger().WithField("username", req.Username).Warn("Login attempt while locked out")
+		c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(int(locked.RetryAfter.Seconds())+1))
+		return echo.NewHTTPError(429, "Too many failed attempts, try again later")
+	}
+	if errors.Is(err, lockout.ErrInvalidCredentials) {
+		r.logger.Logger().WithField("username", req.Username).Warn("Login attempt with invalid credentials")
 		return echo.NewHTTPError(401, "Invalid credentials")
 	}
+	if err != nil {
+		r.logger.Logger().WithError(err).Error("Failed to verify credentials")
+		return echo.NewHTTPError(500, "Failed to verify credentials")
+	}
 
-	r.logger.Logger().WithField("user_id", user.ID).Info("User found", user)
-
-	// Verify password
-	if err := r.security.VerifyPassword(user.Password, req.Password); err != nil {
-		r.logger.Logger().WithField("user_id", user.ID).Warn("Login attempt with invalid password")
-		return echo.NewHTTPError(401, "Invalid credentials")
+	if rehashed != "" {
+		if err := r.db.Connection().Model(&user).Update("password", rehashed).Error; err != nil {
+			r.logger.Logger().WithError(err).Warn("Failed to upgrade password hash")
+		}
 	}
 
 	// Generate token pair (access + refresh token) using strongly-typed interface
@@ -204,13 +247,20 @@ func (r *APIRoutes) RefreshToken(c echo.Context) error {
 		return echo.NewHTTPError(401, "Invalid or expired refresh token")
 	}
 
-	return c.JSON(200, map[string]interface{}{
+	response := map[string]interface{}{
 		"access_token": tokenResponse.AccessToken,
 		"token_type":   tokenResponse.TokenType,
 		"expires_in":   tokenResponse.ExpiresIn,
 		"expires_at":   tokenResponse.ExpiresAt,
 		"message":      "Token refreshed successfully",
-	})
+	}
+
+	// With refresh token rotation the old refresh token is now spent
+	if tokenResponse.RefreshToken != "" {
+		response["refresh_token"] = tokenResponse.RefreshToken
+	}
+
+	return c.JSON(200, response)
 }
 
 func (r *APIRoutes) GetProfile(c echo.Context) error {
@@ -270,6 +320,7 @@ type AppConfig struct {
 	Database      database.Config      `mapstructure:"database"`
 	HTTP          http.Config          `mapstructure:"http"`
 	Security      security.Config      `mapstructure:"security"`
+	Lockout       lockout.Config       `mapstructure:"lockout"`
 	Observability observability.Config `mapstructure:"observability"`
 }
 
@@ -334,7 +385,7 @@ func StartApplication(
 			obsService.Logger().Info("Starting microservices application...")
 
 			// Auto-migrate database schema
-			if err := dbService.Connection().AutoMigrate(&User{}); err != nil {
+			if err := dbService.Connection().AutoMigrate(&User{}, &lockout.Record{}); err != nil {
 				obsService.Logger().WithError(err).Fatal("Failed to migrate database")
 				return err
 			}
@@ -403,12 +454,14 @@ func main() {
 		fx.Provide(func() database.Config { return config.Database }),
 		fx.Provide(func() http.Config { return config.HTTP }),
 		fx.Provide(func() security.Config { return config.Security }),
+		fx.Provide(func() lockout.Config { return config.Lockout }),
 		fx.Provide(func() observability.Config { return config.Observability }),
 
 		// Infrastructure modules
 		database.Module,
 		http.Module,
 		security.Module,
+		lockout.Module,
 		observability.Module,
 
 		// Application services
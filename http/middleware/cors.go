@@ -2,22 +2,257 @@
 package middleware
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/labstack/echo/v4"
-	"github.com/labstack/echo/v4/middleware"
 )
 
+// ErrCORSWildcardCredentials rejects policies that would let every origin
+// make credentialed requests
+var ErrCORSWildcardCredentials = errors.New("cors: credentials require explicit origins or patterns, not \"*\"")
+
+var (
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	defaultCORSHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+)
+
+// CORSConfig is a CORS policy. AllowOrigins entries are exact origins, "*",
+// or wildcard subdomains such as "https://*.example.com"; AllowOriginPatterns
+// are regular expressions matched against the whole origin.
+type CORSConfig struct {
+	AllowOrigins        []string      `mapstructure:"allow_origins"`
+	AllowOriginPatterns []string      `mapstructure:"allow_origin_patterns"`
+	AllowMethods        []string      `mapstructure:"allow_methods"`
+	AllowHeaders        []string      `mapstructure:"allow_headers"`
+	ExposeHeaders       []string      `mapstructure:"expose_headers"`
+	AllowCredentials    bool          `mapstructure:"allow_credentials"`
+	MaxAge              time.Duration `mapstructure:"max_age"`
+
+	// Routes override the policy for request paths under Path, matched on
+	// whole path segments; the longest matching prefix wins
+	Routes []CORSRoute `mapstructure:"routes"`
+}
+
+// CORSRoute is a CORS policy for a path prefix
+type CORSRoute struct {
+	Path   string     `mapstructure:"path"`
+	Policy CORSConfig `mapstructure:",squash"`
+}
+
+type corsPolicy struct {
+	allowAll      bool
+	origins       map[string]bool
+	subdomains    []string // "scheme://" + ".suffix" pairs, see allowsOrigin
+	patterns      []*regexp.Regexp
+	methods       string
+	headers       string
+	exposeHeaders string
+	credentials   bool
+	maxAge        string
+}
+
+type corsRoute struct {
+	prefix string
+	policy *corsPolicy
+}
+
 func CORS() echo.MiddlewareFunc {
-	return middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"*"},
-		AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders: []string{"*"},
-	})
+	middleware, _ := NewCORS(CORSConfig{AllowOrigins: []string{"*"}, AllowHeaders: []string{"*"}})
+	return middleware
 }
 
 func CORSWithConfig(origins []string, methods []string, headers []string) echo.MiddlewareFunc {
-	return middleware.CORSWithConfig(middleware.CORSConfig{
+	// Only origin patterns can fail to compile
+	middleware, _ := NewCORS(CORSConfig{
 		AllowOrigins: origins,
 		AllowMethods: methods,
 		AllowHeaders: headers,
 	})
+	return middleware
+}
+
+// NewCORS builds the CORS middleware for config. Responses that depend on
+// the request origin carry Vary: Origin so shared caches keep them apart.
+func NewCORS(config CORSConfig) (echo.MiddlewareFunc, error) {
+	policy, err := newCORSPolicy(config)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make([]corsRoute, 0, len(config.Routes))
+	for _, route := range config.Routes {
+		routePolicy, err := newCORSPolicy(route.Policy)
+		if err != nil {
+			return nil, fmt.Errorf("cors route %q: %w", route.Path, err)
+		}
+		routes = append(routes, corsRoute{prefix: strings.TrimSuffix(route.Path, "/"), policy: routePolicy})
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		return len(routes[i].prefix) > len(routes[j].prefix)
+	})
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			current := policy
+			for _, route := range routes {
+				if route.matches(c.Request().URL.Path) {
+					current = route.policy
+					break
+				}
+			}
+			return current.handle(c, next)
+		}
+	}, nil
+}
+
+// matches reports whether path is the prefix or below it, so "/api" matches
+// "/api/items" but not "/apiary"
+func (r corsRoute) matches(path string) bool {
+	if r.prefix == "" || path == r.prefix {
+		return true
+	}
+	return strings.HasPrefix(path, r.prefix+"/")
+}
+
+// newCORSPolicy builds a policy. Without origins or patterns every origin is
+// allowed, which cannot be combined with credentials.
+func newCORSPolicy(config CORSConfig) (*corsPolicy, error) {
+	if len(config.AllowOrigins) == 0 && len(config.AllowOriginPatterns) == 0 {
+		config.AllowOrigins = []string{"*"}
+	}
+	if len(config.AllowMethods) == 0 {
+		config.AllowMethods = defaultCORSMethods
+	}
+
+	policy := &corsPolicy{
+		origins:       make(map[string]bool),
+		methods:       strings.Join(config.AllowMethods, ", "),
+		exposeHeaders: strings.Join(config.ExposeHeaders, ", "),
+		credentials:   config.AllowCredentials,
+	}
+
+	// "*" reflects whatever headers the preflight asks for
+	switch {
+	case len(config.AllowHeaders) == 0:
+		policy.headers = strings.Join(defaultCORSHeaders, ", ")
+	case len(config.AllowHeaders) == 1 && config.AllowHeaders[0] == "*":
+	default:
+		policy.headers = strings.Join(config.AllowHeaders, ", ")
+	}
+	if config.MaxAge > 0 {
+		policy.maxAge = strconv.Itoa(int(config.MaxAge.Seconds()))
+	}
+
+	for _, origin := range config.AllowOrigins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		switch {
+		case origin == "*":
+			policy.allowAll = true
+		case strings.Contains(origin, "://*."):
+			policy.subdomains = append(policy.subdomains, strings.Replace(origin, "://*.", "://.", 1))
+		default:
+			policy.origins[strings.TrimSuffix(origin, "/")] = true
+		}
+	}
+
+	for _, pattern := range config.AllowOriginPatterns {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid cors origin pattern %q: %w", pattern, err)
+		}
+		policy.patterns = append(policy.patterns, re)
+	}
+
+	// Reflecting every origin with credentials lets any site read responses
+	// with the user's cookies
+	if policy.allowAll && policy.credentials {
+		return nil, ErrCORSWildcardCredentials
+	}
+
+	return policy, nil
+}
+
+func (p *corsPolicy) allowsOrigin(origin string) bool {
+	if p.allowAll {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	if p.origins[origin] {
+		return true
+	}
+
+	for _, subdomain := range p.subdomains {
+		// "https://.example.com" matches "https://api.example.com"
+		scheme, suffix, _ := strings.Cut(subdomain, "://")
+		host, found := strings.CutPrefix(origin, scheme+"://")
+		if found && strings.HasSuffix(host, suffix) && len(host) > len(suffix) {
+			return true
+		}
+	}
+
+	for _, pattern := range p.patterns {
+		if pattern.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *corsPolicy) handle(c echo.Context, next echo.HandlerFunc) error {
+	req := c.Request()
+	header := c.Response().Header()
+	preflight := req.Method == http.MethodOptions && req.Header.Get(echo.HeaderAccessControlRequestMethod) != ""
+
+	// A fixed "*" answer is the same for every origin; anything else varies
+	reflects := !p.allowAll
+	if reflects {
+		header.Add(echo.HeaderVary, echo.HeaderOrigin)
+	}
+	if preflight {
+		header.Add(echo.HeaderVary, echo.HeaderAccessControlRequestMethod)
+		header.Add(echo.HeaderVary, echo.HeaderAccessControlRequestHeaders)
+	}
+
+	origin := req.Header.Get(echo.HeaderOrigin)
+	if origin == "" || !p.allowsOrigin(origin) {
+		if preflight {
+			return c.NoContent(http.StatusNoContent)
+		}
+		return next(c)
+	}
+
+	if reflects {
+		header.Set(echo.HeaderAccessControlAllowOrigin, origin)
+	} else {
+		header.Set(echo.HeaderAccessControlAllowOrigin, "*")
+	}
+	if p.credentials {
+		header.Set(echo.HeaderAccessControlAllowCredentials, "true")
+	}
+
+	if !preflight {
+		if p.exposeHeaders != "" {
+			header.Set(echo.HeaderAccessControlExposeHeaders, p.exposeHeaders)
+		}
+		return next(c)
+	}
+
+	header.Set(echo.HeaderAccessControlAllowMethods, p.methods)
+	if p.headers != "" {
+		header.Set(echo.HeaderAccessControlAllowHeaders, p.headers)
+	} else if requested := req.Header.Get(echo.HeaderAccessControlRequestHeaders); requested != "" {
+		header.Set(echo.HeaderAccessControlAllowHeaders, requested)
+	}
+	if p.maxAge != "" {
+		header.Set(echo.HeaderAccessControlMaxAge, p.maxAge)
+	}
+	return c.NoContent(http.StatusNoContent)
 }
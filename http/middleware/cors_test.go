@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCORSServer(t *testing.T, config CORSConfig) *echo.Echo {
+	t.Helper()
+
+	cors, err := NewCORS(config)
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.Use(cors)
+	handler := func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}
+	e.GET("/api/items", handler)
+	e.GET("/public/feed", handler)
+	return e
+}
+
+func corsRequest(e *echo.Echo, method, path, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if origin != "" {
+		req.Header.Set(echo.HeaderOrigin, origin)
+	}
+	if method == http.MethodOptions {
+		req.Header.Set(echo.HeaderAccessControlRequestMethod, http.MethodGet)
+		req.Header.Set(echo.HeaderAccessControlRequestHeaders, "X-Custom")
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCORS_AllowList(t *testing.T) {
+	e := newCORSServer(t, CORSConfig{
+		AllowOrigins:        []string{"https://app.example.com", "https://*.example.org"},
+		AllowOriginPatterns: []string{`https://pr-\d+\.preview\.example\.net`},
+		AllowHeaders:        []string{"Authorization", "X-Custom"},
+		ExposeHeaders:       []string{"RateLimit-Remaining"},
+		AllowCredentials:    true,
+		MaxAge:              10 * time.Minute,
+	})
+
+	for _, origin := range []string{"https://app.example.com", "https://api.example.org", "https://pr-42.preview.example.net"} {
+		rec := corsRequest(e, http.MethodGet, "/api/items", origin)
+		assert.Equal(t, origin, rec.Header().Get(echo.HeaderAccessControlAllowOrigin), origin)
+		assert.Equal(t, "true", rec.Header().Get(echo.HeaderAccessControlAllowCredentials))
+		assert.Equal(t, "RateLimit-Remaining", rec.Header().Get(echo.HeaderAccessControlExposeHeaders))
+		assert.Contains(t, rec.Header().Values(echo.HeaderVary), echo.HeaderOrigin)
+	}
+
+	for _, origin := range []string{"https://evil.com", "https://example.org", "http://api.example.org", "https://pr-x.preview.example.net", "https://app.example.com.evil.com"} {
+		rec := corsRequest(e, http.MethodGet, "/api/items", origin)
+		assert.Empty(t, rec.Header().Get(echo.HeaderAccessControlAllowOrigin), origin)
+		assert.Contains(t, rec.Header().Values(echo.HeaderVary), echo.HeaderOrigin)
+	}
+
+	rec := corsRequest(e, http.MethodOptions, "/api/items", "https://app.example.com")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "Authorization, X-Custom", rec.Header().Get(echo.HeaderAccessControlAllowHeaders))
+	assert.Equal(t, "600", rec.Header().Get(echo.HeaderAccessControlMaxAge))
+	assert.NotEmpty(t, rec.Header().Get(echo.HeaderAccessControlAllowMethods))
+}
+
+func TestCORS_Wildcard(t *testing.T) {
+	e := newCORSServer(t, CORSConfig{
+		AllowOrigins: []string{"https://app.example.com"},
+		Routes: []CORSRoute{
+			{Path: "/public/", Policy: CORSConfig{AllowOrigins: []string{"*"}, AllowHeaders: []string{"*"}}},
+		},
+	})
+
+	rec := corsRequest(e, http.MethodGet, "/public/feed", "https://anyone.com")
+	assert.Equal(t, "*", rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
+	assert.NotContains(t, rec.Header().Values(echo.HeaderVary), echo.HeaderOrigin)
+
+	rec = corsRequest(e, http.MethodOptions, "/public/feed", "https://anyone.com")
+	assert.Equal(t, "X-Custom", rec.Header().Get(echo.HeaderAccessControlAllowHeaders))
+
+	rec = corsRequest(e, http.MethodGet, "/api/items", "https://anyone.com")
+	assert.Empty(t, rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
+
+	_, err := NewCORS(CORSConfig{AllowOriginPatterns: []string{"("}})
+	assert.Error(t, err)
+}
+
+func TestCORS_RejectsWildcardWithCredentials(t *testing.T) {
+	_, err := NewCORS(CORSConfig{AllowOrigins: []string{"*"}, AllowCredentials: true})
+	assert.ErrorIs(t, err, ErrCORSWildcardCredentials)
+
+	// No origins means every origin
+	_, err = NewCORS(CORSConfig{AllowCredentials: true})
+	assert.ErrorIs(t, err, ErrCORSWildcardCredentials)
+
+	_, err = NewCORS(CORSConfig{
+		AllowOrigins: []string{"https://app.example.com"},
+		Routes:       []CORSRoute{{Path: "/public", Policy: CORSConfig{AllowCredentials: true}}},
+	})
+	assert.ErrorIs(t, err, ErrCORSWildcardCredentials)
+}
+
+func TestCORS_RoutesMatchPathSegments(t *testing.T) {
+	e := newCORSServer(t, CORSConfig{
+		AllowOrigins: []string{"https://app.example.com"},
+		Routes:       []CORSRoute{{Path: "/api", Policy: CORSConfig{AllowOrigins: []string{"*"}}}},
+	})
+
+	for _, path := range []string{"/api", "/api/items"} {
+		rec := corsRequest(e, http.MethodGet, path, "https://anyone.com")
+		assert.Equal(t, "*", rec.Header().Get(echo.HeaderAccessControlAllowOrigin), path)
+	}
+
+	rec := corsRequest(e, http.MethodGet, "/apiary", "https://anyone.com")
+	assert.Empty(t, rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
+}
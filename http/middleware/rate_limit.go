@@ -2,19 +2,28 @@
 package middleware
 
 import (
+	"time"
+
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
-	"golang.org/x/time/rate"
 )
 
+// RateLimit allows 10 requests per second per client address.
+//
+// Deprecated: use the RateLimitMiddleware of security/middleware, which
+// also limits per user, client or API key and supports shared stores.
 func RateLimit() echo.MiddlewareFunc {
-	return middleware.RateLimiter(middleware.NewRateLimiterMemoryStore(
-		rate.Limit(10), // 10 requests per second
-	))
+	return RateLimitWithConfig(10) // 10 requests per second
 }
 
+// RateLimitWithConfig allows requestsPerSecond requests per client address,
+// in bursts of up to as many, tracked in a process-local GCRA store.
+//
+// Deprecated: use the RateLimitMiddleware of security/middleware, which
+// also limits per user, client or API key and supports shared stores.
 func RateLimitWithConfig(requestsPerSecond float64) echo.MiddlewareFunc {
-	return middleware.RateLimiter(middleware.NewRateLimiterMemoryStore(
-		rate.Limit(requestsPerSecond),
+	return middleware.RateLimiter(NewEchoRateLimiterStore(
+		NewMemoryRateLimitStore(),
+		Limit{Rate: requestsPerSecond, Period: time.Second},
 	))
 }
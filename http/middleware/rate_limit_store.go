@@ -0,0 +1,145 @@
+// http/middleware/rate_limit_store.go
+package middleware
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4/middleware"
+)
+
+const rateLimitSweepInterval = time.Minute
+
+// RateLimitStore keeps rate limit state, shared by all instances when the
+// store is external
+type RateLimitStore interface {
+	// Allow counts a request against key and reports whether it is allowed
+	Allow(key string, limit Limit, now time.Time) (RateLimitResult, error)
+}
+
+// Limit allows Rate requests per Period with bursts of up to Burst requests
+type Limit struct {
+	Rate   float64
+	Burst  int
+	Period time.Duration
+}
+
+// Interval is the time between two requests at the sustained rate
+func (l Limit) Interval() time.Duration {
+	period := l.Period
+	if period <= 0 {
+		period = time.Second
+	}
+	if l.Rate <= 0 {
+		return period
+	}
+	return time.Duration(float64(period) / l.Rate)
+}
+
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return int(math.Max(1, math.Ceil(l.Rate)))
+}
+
+// RateLimitResult is the outcome of a rate limit check
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// ResetAfter is when the bucket is full again
+	ResetAfter time.Duration
+	// RetryAfter is when a denied request may be retried
+	RetryAfter time.Duration
+}
+
+// GCRA applies the generic cell rate algorithm to the theoretical arrival time
+// of the next request, returning the new arrival time to store
+func GCRA(tat time.Time, limit Limit, now time.Time) (time.Time, RateLimitResult) {
+	interval := limit.Interval()
+	burst := limit.burst()
+	tolerance := interval * time.Duration(burst)
+
+	if tat.Before(now) {
+		tat = now
+	}
+	newTat := tat.Add(interval)
+	allowAt := newTat.Add(-tolerance)
+
+	result := RateLimitResult{Limit: burst}
+	if now.Before(allowAt) {
+		result.RetryAfter = allowAt.Sub(now)
+		result.ResetAfter = tat.Sub(now)
+		return tat, result
+	}
+
+	result.Allowed = true
+	result.Remaining = int(now.Sub(allowAt) / interval)
+	result.ResetAfter = newTat.Sub(now)
+	return newTat, result
+}
+
+// EchoRateLimiterStore adapts a RateLimitStore to echo's RateLimiter
+// middleware, applying the same limit to every identifier
+type EchoRateLimiterStore struct {
+	Store RateLimitStore
+	Limit Limit
+}
+
+// NewEchoRateLimiterStore creates an echo store, e.g. for
+// middleware.RateLimiter(NewEchoRateLimiterStore(gormStore, limit))
+func NewEchoRateLimiterStore(store RateLimitStore, limit Limit) middleware.RateLimiterStore {
+	return &EchoRateLimiterStore{
+		Store: store,
+		Limit: limit,
+	}
+}
+
+func (s *EchoRateLimiterStore) Allow(identifier string) (bool, error) {
+	result, err := s.Store.Allow(identifier, s.Limit, time.Now())
+	if err != nil {
+		return false, err
+	}
+	return result.Allowed, nil
+}
+
+type memoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryRateLimitStore creates a process-local GCRA store. Keys whose
+// bucket has refilled are evicted, so idle clients use no memory.
+func NewMemoryRateLimitStore() RateLimitStore {
+	return &memoryRateLimitStore{
+		buckets: make(map[string]time.Time),
+	}
+}
+
+func (s *memoryRateLimitStore) Allow(key string, limit Limit, now time.Time) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	tat, result := GCRA(s.buckets[key], limit, now)
+	s.buckets[key] = tat
+	return result, nil
+}
+
+// sweep drops full buckets; a missing key behaves exactly like a full one
+func (s *memoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, tat := range s.buckets {
+		if !tat.After(now) {
+			delete(s.buckets, key)
+		}
+	}
+}
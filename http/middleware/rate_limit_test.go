@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryRateLimitStore_EvictsIdleKeys(t *testing.T) {
+	store := NewMemoryRateLimitStore().(*memoryRateLimitStore)
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	limit := Limit{Rate: 1, Period: time.Second}
+
+	for _, key := range []string{"a", "b", "c"} {
+		_, err := store.Allow(key, limit, now)
+		assert.NoError(t, err)
+	}
+	assert.Len(t, store.buckets, 3)
+
+	_, err := store.Allow("d", limit, now.Add(2*time.Minute))
+	assert.NoError(t, err)
+	assert.Len(t, store.buckets, 1)
+}
+
+func TestRateLimitWithConfig(t *testing.T) {
+	e := echo.New()
+	e.Use(RateLimitWithConfig(2))
+	e.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	request := func(ip string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	assert.Equal(t, http.StatusNoContent, request("192.0.2.1"))
+	assert.Equal(t, http.StatusNoContent, request("192.0.2.1"))
+	assert.Equal(t, http.StatusTooManyRequests, request("192.0.2.1"))
+	assert.Equal(t, http.StatusNoContent, request("192.0.2.2"))
+}
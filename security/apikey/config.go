@@ -0,0 +1,15 @@
+package apikey
+
+import "time"
+
+type Config struct {
+	// Prefix starts every generated key, e.g. "fk" gives "fk_<id>_<secret>".
+	// It makes keys recognizable to secret scanners.
+	Prefix string `mapstructure:"prefix"`
+	// Header carries the key; "Authorization: ApiKey <key>" is accepted too
+	Header string `mapstructure:"header"`
+	// DefaultTTL applies to keys created without an expiry; zero means never
+	DefaultTTL time.Duration `mapstructure:"default_ttl"`
+	// LastUsedInterval throttles last-used updates, defaults to one minute
+	LastUsedInterval time.Duration `mapstructure:"last_used_interval"`
+}
@@ -0,0 +1,5 @@
+package apikey
+
+import "go.uber.org/fx"
+
+var Module = fx.Provide(NewService)
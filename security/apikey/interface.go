@@ -0,0 +1,33 @@
+package apikey
+
+import (
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/upnext-fng/fulcrum/security/jwt"
+)
+
+type Service interface {
+	Create(request CreateRequest) (*CreatedKey, error)
+	// Authenticate resolves a presented key and records its use
+	Authenticate(key string) (*APIKey, error)
+	Revoke(id string) error
+	List(ownerID string) ([]APIKey, error)
+
+	// Claims describes a key in the shape JWTMiddleware produces
+	Claims(key *APIKey) *jwt.Claims
+
+	// Middleware authenticates requests by API key
+	Middleware() echo.MiddlewareFunc
+	// MiddlewareOr authenticates by API key when one is presented and
+	// defers to fallback, e.g. the JWT middleware, otherwise
+	MiddlewareOr(fallback echo.MiddlewareFunc) echo.MiddlewareFunc
+}
+
+type Store interface {
+	Create(key *APIKey) error
+	Find(id string) (*APIKey, error)
+	ListByOwner(ownerID string) ([]APIKey, error)
+	Revoke(id string, revokedAt time.Time) error
+	TouchLastUsed(id string, usedAt time.Time) error
+}
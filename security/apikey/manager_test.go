@@ -0,0 +1,116 @@
+package apikey
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/upnext-fng/fulcrum/security/middleware"
+)
+
+func TestAPIKey_Lifecycle(t *testing.T) {
+	store := NewMemoryStore()
+	service := NewManager(Config{Prefix: "svc"}, store)
+
+	created, err := service.Create(CreateRequest{OwnerID: "test-user-123", Name: "ci", Scopes: []string{"read"}})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(created.Key, "svc_"+created.APIKey.ID+"_"))
+	assert.NotContains(t, created.APIKey.Hash, created.Key)
+
+	apiKey, err := service.Authenticate(created.Key)
+	require.NoError(t, err)
+	assert.Equal(t, "test-user-123", apiKey.OwnerID)
+	assert.Equal(t, []string{"read"}, apiKey.Scopes)
+
+	stored, err := store.Find(apiKey.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, stored.LastUsedAt)
+
+	_, err = service.Authenticate(created.Key + "x")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	_, err = service.Authenticate("svc_" + strings.Repeat("0", 32) + "_secret")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	keys, err := service.List("test-user-123")
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+
+	require.NoError(t, service.Revoke(apiKey.ID))
+	_, err = service.Authenticate(created.Key)
+	assert.ErrorIs(t, err, ErrKeyRevoked)
+
+	_, err = service.Create(CreateRequest{})
+	assert.ErrorIs(t, err, ErrInvalidOwnerID)
+}
+
+func TestAPIKey_Expiry(t *testing.T) {
+	service := NewManager(Config{}, NewMemoryStore())
+
+	expiresAt := time.Now().Add(-time.Minute)
+	created, err := service.Create(CreateRequest{OwnerID: "test-user-123", ExpiresAt: &expiresAt})
+	require.NoError(t, err)
+
+	_, err = service.Authenticate(created.Key)
+	assert.ErrorIs(t, err, ErrKeyExpired)
+
+	withTTL := NewManager(Config{DefaultTTL: time.Hour}, NewMemoryStore())
+	created, err = withTTL.Create(CreateRequest{OwnerID: "test-user-123"})
+	require.NoError(t, err)
+	require.NotNil(t, created.APIKey.ExpiresAt)
+}
+
+func TestAPIKey_Middleware(t *testing.T) {
+	service := NewManager(Config{}, NewMemoryStore())
+	created, err := service.Create(CreateRequest{OwnerID: "test-user-123", Scopes: []string{"reports:read"}})
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.GET("/reports", func(c echo.Context) error {
+		userID, _ := middleware.UserIDFrom(c.Request().Context())
+		return c.String(http.StatusOK, userID)
+	}, service.Middleware(), middleware.RequireScopes("reports:read"))
+
+	serve := func(header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/reports", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("X-API-Key", created.Key)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "test-user-123", rec.Body.String())
+
+	rec = serve(echo.HeaderAuthorization, "ApiKey "+created.Key)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	assert.Equal(t, http.StatusUnauthorized, serve("X-API-Key", "fk_bogus").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("", "").Code)
+}
+
+func TestAPIKey_MiddlewareOr(t *testing.T) {
+	service := NewManager(Config{}, NewMemoryStore())
+
+	fallback := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			return c.String(http.StatusTeapot, "fallback")
+		}
+	}
+
+	e := echo.New()
+	e.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, service.MiddlewareOr(fallback))
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusTeapot, rec.Code)
+}
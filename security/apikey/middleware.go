@@ -0,0 +1,59 @@
+package apikey
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/upnext-fng/fulcrum/security/middleware"
+)
+
+const authorizationScheme = "ApiKey"
+
+func (m *manager) Middleware() echo.MiddlewareFunc {
+	return m.MiddlewareOr(nil)
+}
+
+func (m *manager) MiddlewareOr(fallback echo.MiddlewareFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		var fallbackHandler echo.HandlerFunc
+		if fallback != nil {
+			fallbackHandler = fallback(next)
+		}
+
+		return func(c echo.Context) error {
+			key := m.extractKey(c)
+			if key == "" {
+				if fallbackHandler != nil {
+					return fallbackHandler(c)
+				}
+				return echo.NewHTTPError(http.StatusUnauthorized, "missing api key")
+			}
+
+			apiKey, err := m.Authenticate(key)
+			if err != nil {
+				if errors.Is(err, ErrInvalidKey) || errors.Is(err, ErrKeyNotFound) ||
+					errors.Is(err, ErrKeyRevoked) || errors.Is(err, ErrKeyExpired) {
+					return echo.NewHTTPError(http.StatusUnauthorized, "invalid api key")
+				}
+				return err
+			}
+
+			// Same claims shape as JWTMiddleware, so authorization works alike
+			return next(middleware.SetPrincipal(c, m.Claims(apiKey), ""))
+		}
+	}
+}
+
+func (m *manager) extractKey(c echo.Context) string {
+	if key := strings.TrimSpace(c.Request().Header.Get(m.config.Header)); key != "" {
+		return key
+	}
+
+	fields := strings.Fields(c.Request().Header.Get(echo.HeaderAuthorization))
+	if len(fields) == 2 && strings.EqualFold(fields[0], authorizationScheme) {
+		return fields[1]
+	}
+	return ""
+}
@@ -0,0 +1,7 @@
+package apikey
+
+import "github.com/upnext-fng/fulcrum/database"
+
+func NewService(config Config, db database.DatabaseService) Service {
+	return NewManager(config, NewGormStore(db))
+}
@@ -0,0 +1,64 @@
+package apikey
+
+import (
+	"errors"
+	"time"
+
+	"github.com/upnext-fng/fulcrum/database"
+	"gorm.io/gorm"
+)
+
+type gormStore struct {
+	db database.DatabaseService
+}
+
+// NewGormStore stores keys in the api_keys table. Migrate APIKey before use.
+func NewGormStore(db database.DatabaseService) Store {
+	return &gormStore{
+		db: db,
+	}
+}
+
+func (s *gormStore) Create(key *APIKey) error {
+	return s.db.Connection().Create(key).Error
+}
+
+func (s *gormStore) Find(id string) (*APIKey, error) {
+	var key APIKey
+	if err := s.db.Connection().Where("id = ?", id).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrKeyNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (s *gormStore) ListByOwner(ownerID string) ([]APIKey, error) {
+	var keys []APIKey
+	err := s.db.Connection().
+		Where("owner_id = ?", ownerID).
+		Order("created_at DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+func (s *gormStore) Revoke(id string, revokedAt time.Time) error {
+	result := s.db.Connection().Model(&APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", revokedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		if _, err := s.Find(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *gormStore) TouchLastUsed(id string, usedAt time.Time) error {
+	return s.db.Connection().Model(&APIKey{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
@@ -0,0 +1,84 @@
+package apikey
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryStore struct {
+	mu   sync.RWMutex
+	keys map[string]*APIKey
+}
+
+// NewMemoryStore creates a process-local store, useful for tests
+func NewMemoryStore() Store {
+	return &memoryStore{
+		keys: make(map[string]*APIKey),
+	}
+}
+
+func (s *memoryStore) Create(key *APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := *key
+	s.keys[key.ID] = &stored
+	return nil
+}
+
+func (s *memoryStore) Find(id string) (*APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+
+	found := *key
+	return &found, nil
+}
+
+func (s *memoryStore) ListByOwner(ownerID string) ([]APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var keys []APIKey
+	for _, key := range s.keys {
+		if key.OwnerID == ownerID {
+			keys = append(keys, *key)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
+
+func (s *memoryStore) Revoke(id string, revokedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[id]
+	if !ok {
+		return ErrKeyNotFound
+	}
+	if key.RevokedAt == nil {
+		key.RevokedAt = &revokedAt
+	}
+	return nil
+}
+
+func (s *memoryStore) TouchLastUsed(id string, usedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[id]
+	if !ok {
+		return ErrKeyNotFound
+	}
+	key.LastUsedAt = &usedAt
+	return nil
+}
@@ -0,0 +1,57 @@
+package apikey
+
+import (
+	"errors"
+	"time"
+)
+
+// APIKey is a stored API key. Only the SHA-256 hash of the key is kept.
+type APIKey struct {
+	ID         string     `json:"id" gorm:"primaryKey;size:32"`
+	Prefix     string     `json:"prefix" gorm:"size:16;not null"`
+	Name       string     `json:"name" gorm:"size:255"`
+	OwnerID    string     `json:"owner_id" gorm:"index;size:255;not null"`
+	Scopes     []string   `json:"scopes" gorm:"serializer:json"`
+	Hash       string     `json:"-" gorm:"size:64;not null"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// IsActiveAt reports whether the key is neither revoked nor expired at t
+func (k *APIKey) IsActiveAt(t time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	if k.ExpiresAt != nil && !t.Before(*k.ExpiresAt) {
+		return false
+	}
+	return true
+}
+
+type CreateRequest struct {
+	OwnerID   string     `json:"owner_id"`
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreatedKey holds a new key. Key is the only copy of the plaintext key and
+// must be shown to the owner once.
+type CreatedKey struct {
+	Key    string  `json:"key"`
+	APIKey *APIKey `json:"api_key"`
+}
+
+var (
+	ErrInvalidKey     = errors.New("invalid api key")
+	ErrKeyNotFound    = errors.New("api key not found")
+	ErrKeyRevoked     = errors.New("api key revoked")
+	ErrKeyExpired     = errors.New("api key expired")
+	ErrInvalidOwnerID = errors.New("invalid owner ID")
+)
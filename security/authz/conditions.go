@@ -0,0 +1,127 @@
+package authz
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var attributeRoots = map[string]bool{
+	"subject":     true,
+	"resource":    true,
+	"environment": true,
+	"action":      true,
+}
+
+func validAttribute(attribute string) bool {
+	root := strings.SplitN(attribute, ".", 2)[0]
+	return attributeRoots[root]
+}
+
+// evaluateCondition checks one condition of a rule with effect. When the
+// attribute, or the ValueFrom attribute, is missing the outcome cannot be
+// known and the policy fails closed: conditions of allow rules do not hold,
+// conditions of deny rules do, whatever the operator. So an empty subject
+// tenant never equals an empty resource one, and "deny if tenant ne X" also
+// denies subjects without a tenant. Only exists tests for presence itself.
+func evaluateCondition(condition ConditionConfig, effect Effect, attributes map[string]interface{}) bool {
+	actual, ok := lookup(attributes, condition.Attribute)
+	if condition.Operator == OperatorExists {
+		return ok
+	}
+	if !ok {
+		return effect == EffectDeny
+	}
+
+	expected := condition.Value
+	if condition.ValueFrom != "" {
+		if expected, ok = lookup(attributes, condition.ValueFrom); !ok {
+			return effect == EffectDeny
+		}
+	}
+
+	switch condition.Operator {
+	case OperatorEquals:
+		return equal(actual, expected)
+	case OperatorNotEquals:
+		return !equal(actual, expected)
+	case OperatorIn:
+		return contains(expected, actual)
+	case OperatorNotIn:
+		return !contains(expected, actual)
+	case OperatorContains:
+		return contains(actual, expected)
+	}
+	return false
+}
+
+// lookup resolves a dotted attribute path, treating nil and empty values as
+// missing
+func lookup(attributes map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = attributes
+	for _, part := range strings.Split(path, ".") {
+		values, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = values[part]; !ok {
+			return nil, false
+		}
+	}
+
+	if current == nil {
+		return nil, false
+	}
+	if value := reflect.ValueOf(current); (value.Kind() == reflect.String || value.Kind() == reflect.Slice || value.Kind() == reflect.Map) && value.Len() == 0 {
+		return nil, false
+	}
+	return current, true
+}
+
+// equal compares scalars loosely, so that numbers decoded from YAML and JSON
+// match whatever integer type the resource attributes use
+func equal(a, b interface{}) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	if isNumber(a) && isNumber(b) {
+		return fmt.Sprint(toFloat(a)) == fmt.Sprint(toFloat(b))
+	}
+	return false
+}
+
+func contains(list, value interface{}) bool {
+	items := reflect.ValueOf(list)
+	if items.Kind() != reflect.Slice && items.Kind() != reflect.Array {
+		return false
+	}
+	for i := 0; i < items.Len(); i++ {
+		if equal(items.Index(i).Interface(), value) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNumber(value interface{}) bool {
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func toFloat(value interface{}) float64 {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	}
+	return 0
+}
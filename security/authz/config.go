@@ -0,0 +1,58 @@
+package authz
+
+// Config holds the authorization policy, usually loaded from the "authz"
+// section of the application configuration:
+//
+//	authz:
+//	  roles:
+//	    - name: editor
+//	      permissions: ["documents:read"]
+//	    - name: admin
+//	      inherits: [editor]
+//	      permissions: ["documents:*"]
+//	  rules:
+//	    - id: editors-update-own-tenant
+//	      effect: allow
+//	      roles: [editor]
+//	      actions: [update]
+//	      resources: [documents]
+//	      conditions:
+//	        - attribute: resource.tenant_id
+//	          operator: eq
+//	          value_from: subject.tenant_id
+type Config struct {
+	Roles []RoleConfig `mapstructure:"roles"`
+	Rules []RuleConfig `mapstructure:"rules"`
+
+	// Explain attaches the evaluation trace to denied responses. Only enable
+	// it while debugging, the trace reveals the policy.
+	Explain bool `mapstructure:"explain"`
+}
+
+// RoleConfig grants permissions of the form "resource:action" to a role and
+// to every role inheriting it. Either part may be "*".
+type RoleConfig struct {
+	Name        string   `mapstructure:"name"`
+	Inherits    []string `mapstructure:"inherits"`
+	Permissions []string `mapstructure:"permissions"`
+}
+
+// RuleConfig allows or denies actions on resources for the listed roles when
+// all conditions hold. An empty list matches anything; deny rules win.
+type RuleConfig struct {
+	ID         string            `mapstructure:"id"`
+	Effect     Effect            `mapstructure:"effect"`
+	Roles      []string          `mapstructure:"roles"`
+	Actions    []string          `mapstructure:"actions"`
+	Resources  []string          `mapstructure:"resources"`
+	Conditions []ConditionConfig `mapstructure:"conditions"`
+}
+
+// ConditionConfig compares an attribute such as "resource.tenant_id" with a
+// literal Value or with another attribute named by ValueFrom
+type ConditionConfig struct {
+	Attribute string      `mapstructure:"attribute"`
+	Operator  Operator    `mapstructure:"operator"`
+	Value     interface{} `mapstructure:"value"`
+	ValueFrom string      `mapstructure:"value_from"`
+}
@@ -0,0 +1,5 @@
+package authz
+
+import "go.uber.org/fx"
+
+var Module = fx.Provide(ServiceProvider)
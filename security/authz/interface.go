@@ -0,0 +1,17 @@
+package authz
+
+import "github.com/labstack/echo/v4"
+
+type Service interface {
+	// Evaluate decides a request; denials are reported in the Decision
+	Evaluate(request Request) Decision
+	// Explain decides a request and records every step of the evaluation
+	Explain(request Request) Decision
+
+	// Middleware authorizes action on the resource returned by resolve for
+	// the subject authenticated by the JWT middleware
+	Middleware(action string, resolve ResourceResolver) echo.MiddlewareFunc
+}
+
+// ResourceResolver loads the resource a request targets
+type ResourceResolver func(c echo.Context) (Resource, error)
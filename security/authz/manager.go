@@ -0,0 +1,285 @@
+package authz
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type manager struct {
+	config Config
+	// roles maps a role to itself and every role it inherits from
+	roles       map[string][]string
+	permissions map[string][]string // declared on each role
+	rules       []RuleConfig
+}
+
+// NewManager compiles the policy in config, rejecting unknown roles,
+// inheritance cycles and malformed rules
+func NewManager(config Config) (Service, error) {
+	m := &manager{
+		config:      config,
+		roles:       make(map[string][]string),
+		permissions: make(map[string][]string),
+		rules:       config.Rules,
+	}
+
+	declared := make(map[string]RoleConfig, len(config.Roles))
+	for _, role := range config.Roles {
+		declared[role.Name] = role
+	}
+
+	for name := range declared {
+		ancestors, err := resolveRole(declared, name, nil)
+		if err != nil {
+			return nil, err
+		}
+		m.roles[name] = ancestors
+		m.permissions[name] = declared[name].Permissions
+	}
+
+	for _, rule := range config.Rules {
+		if err := validateRule(rule); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+func resolveRole(declared map[string]RoleConfig, name string, path []string) ([]string, error) {
+	for _, visited := range path {
+		if visited == name {
+			return nil, fmt.Errorf("%w: %s", ErrRoleCycle, strings.Join(append(path, name), " -> "))
+		}
+	}
+
+	role, ok := declared[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownRole, name)
+	}
+
+	resolved := []string{name}
+	for _, parent := range role.Inherits {
+		ancestors, err := resolveRole(declared, parent, append(path, name))
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, ancestors...)
+	}
+	return resolved, nil
+}
+
+func validateRule(rule RuleConfig) error {
+	if rule.Effect != EffectAllow && rule.Effect != EffectDeny {
+		return fmt.Errorf("%w: rule %q has effect %q", ErrInvalidEffect, rule.ID, rule.Effect)
+	}
+
+	for _, condition := range rule.Conditions {
+		switch condition.Operator {
+		case OperatorEquals, OperatorNotEquals, OperatorIn, OperatorNotIn, OperatorContains, OperatorExists:
+		default:
+			return fmt.Errorf("%w: rule %q uses %q", ErrInvalidOperator, rule.ID, condition.Operator)
+		}
+
+		for _, attribute := range []string{condition.Attribute, condition.ValueFrom} {
+			if attribute != "" && !validAttribute(attribute) {
+				return fmt.Errorf("%w: rule %q refers to %q", ErrInvalidAttribute, rule.ID, attribute)
+			}
+		}
+		if condition.Attribute == "" {
+			return fmt.Errorf("%w: rule %q has a condition without attribute", ErrInvalidAttribute, rule.ID)
+		}
+	}
+	return nil
+}
+
+func (m *manager) Evaluate(request Request) Decision {
+	return m.evaluate(request, nil)
+}
+
+func (m *manager) Explain(request Request) Decision {
+	trace := make([]TraceEntry, 0)
+	decision := m.evaluate(request, &trace)
+	decision.Trace = trace
+	return decision
+}
+
+func (m *manager) evaluate(request Request, trace *[]TraceEntry) Decision {
+	record := func(step string, matched bool, detail string) {
+		if trace != nil {
+			*trace = append(*trace, TraceEntry{Step: step, Matched: matched, Detail: detail})
+		}
+	}
+
+	roles := m.subjectRoles(request)
+	record("roles", len(roles) > 0, strings.Join(roles, ", "))
+
+	attributes := requestAttributes(request)
+
+	// Deny rules win over anything else, so every rule is evaluated
+	var allowedBy *RuleConfig
+	for i := range m.rules {
+		rule := &m.rules[i]
+
+		matched, detail := m.matchRule(rule, roles, request, attributes)
+		record("rule "+rule.ID, matched, detail)
+		if !matched {
+			continue
+		}
+
+		if rule.Effect == EffectDeny {
+			return Decision{
+				Allowed: false,
+				Reason:  fmt.Sprintf("denied by rule %q", rule.ID),
+				RuleID:  rule.ID,
+			}
+		}
+		if allowedBy == nil {
+			allowedBy = rule
+		}
+	}
+
+	if allowedBy != nil {
+		return Decision{
+			Allowed: true,
+			Reason:  fmt.Sprintf("allowed by rule %q", allowedBy.ID),
+			RuleID:  allowedBy.ID,
+		}
+	}
+
+	for _, permission := range request.Subject.Permissions {
+		if permissionMatches(permission, request.Resource.Type, request.Action) {
+			record("permission "+permission, true, "granted to the subject")
+			return Decision{
+				Allowed: true,
+				Reason:  fmt.Sprintf("subject has permission %q", permission),
+			}
+		}
+	}
+
+	for _, role := range roles {
+		for _, permission := range m.permissions[role] {
+			if permissionMatches(permission, request.Resource.Type, request.Action) {
+				record("permission "+permission, true, "granted to "+role)
+				return Decision{
+					Allowed: true,
+					Reason:  fmt.Sprintf("role %q has permission %q", role, permission),
+				}
+			}
+		}
+	}
+	record("permissions", false, fmt.Sprintf("no role or subject permission grants %s:%s", request.Resource.Type, request.Action))
+
+	return Decision{
+		Allowed: false,
+		Reason:  fmt.Sprintf("no rule or permission allows %q on %q", request.Action, request.Resource.Type),
+	}
+}
+
+// subjectRoles expands the subject's roles with every role they inherit
+// from. Roles missing from the policy only match rules naming them directly.
+func (m *manager) subjectRoles(request Request) []string {
+	var roles []string
+	seen := make(map[string]bool)
+	add := func(role string) {
+		if role != "" && !seen[role] {
+			seen[role] = true
+			roles = append(roles, role)
+		}
+	}
+
+	for _, role := range append([]string{request.Subject.Role}, request.Roles...) {
+		inherited, ok := m.roles[role]
+		if !ok {
+			add(role)
+			continue
+		}
+		for _, r := range inherited {
+			add(r)
+		}
+	}
+	return roles
+}
+
+func (m *manager) matchRule(rule *RuleConfig, roles []string, request Request, attributes map[string]interface{}) (bool, string) {
+	if len(rule.Roles) > 0 && !intersects(rule.Roles, roles) {
+		return false, "role does not match"
+	}
+	if !matchesAny(rule.Actions, request.Action) {
+		return false, "action does not match"
+	}
+	if !matchesAny(rule.Resources, request.Resource.Type) {
+		return false, "resource does not match"
+	}
+
+	for _, condition := range rule.Conditions {
+		if !evaluateCondition(condition, rule.Effect, attributes) {
+			return false, fmt.Sprintf("condition %s %s failed", condition.Attribute, condition.Operator)
+		}
+	}
+	return true, ""
+}
+
+// requestAttributes exposes the request to conditions as nested maps keyed
+// by subject, resource, environment and action
+func requestAttributes(request Request) map[string]interface{} {
+	subject := make(map[string]interface{})
+	if raw, err := json.Marshal(request.Subject); err == nil {
+		_ = json.Unmarshal(raw, &subject)
+	}
+
+	resource := make(map[string]interface{}, len(request.Resource.Attributes)+2)
+	for key, value := range request.Resource.Attributes {
+		resource[key] = value
+	}
+	resource["type"] = request.Resource.Type
+	resource["id"] = request.Resource.ID
+
+	environment := request.Environment
+	if environment == nil {
+		environment = map[string]interface{}{}
+	}
+
+	return map[string]interface{}{
+		"subject":     subject,
+		"resource":    resource,
+		"environment": environment,
+		"action":      request.Action,
+	}
+}
+
+func permissionMatches(permission, resource, action string) bool {
+	if permission == "*" {
+		return true
+	}
+
+	parts := strings.SplitN(permission, ":", 2)
+	if len(parts) != 2 {
+		return false
+	}
+	return (parts[0] == "*" || parts[0] == resource) && (parts[1] == "*" || parts[1] == action)
+}
+
+func matchesAny(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if pattern == "*" || pattern == value {
+			return true
+		}
+	}
+	return false
+}
+
+func intersects(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
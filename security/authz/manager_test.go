@@ -0,0 +1,185 @@
+package authz
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/upnext-fng/fulcrum/configuration"
+	"github.com/upnext-fng/fulcrum/security"
+	"github.com/upnext-fng/fulcrum/security/jwt"
+	"github.com/upnext-fng/fulcrum/security/middleware"
+)
+
+const testPolicy = `
+authz:
+  roles:
+    - name: viewer
+      permissions: ["documents:read"]
+    - name: editor
+      inherits: [viewer]
+    - name: admin
+      inherits: [editor]
+      permissions: ["documents:*"]
+  rules:
+    - id: editors-update-own-tenant
+      effect: allow
+      roles: [editor]
+      actions: [update]
+      resources: [documents]
+      conditions:
+        - attribute: resource.tenant_id
+          operator: eq
+          value_from: subject.tenant_id
+    - id: no-archived-changes
+      effect: deny
+      actions: [update, delete]
+      resources: [documents]
+      conditions:
+        - attribute: resource.status
+          operator: in
+          value: [archived, locked]
+`
+
+func newTestService(t *testing.T) Service {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(testPolicy), 0o600))
+
+	service, err := ServiceProvider(configuration.NewManager(configuration.Config{ConfigPath: dir}))
+	require.NoError(t, err)
+	return service
+}
+
+func document(tenantID, status string) Resource {
+	return Resource{
+		Type:       "documents",
+		ID:         "doc-1",
+		Attributes: map[string]interface{}{"tenant_id": tenantID, "status": status},
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	service := newTestService(t)
+
+	editor := security.UserClaims{UserID: "u1", Role: "editor", TenantID: "acme"}
+	admin := security.UserClaims{UserID: "u2", Role: "admin"}
+
+	tests := []struct {
+		name    string
+		request Request
+		allowed bool
+	}{
+		{"inherited permission", Request{Subject: editor, Action: "read", Resource: document("other", "")}, true},
+		{"own tenant", Request{Subject: editor, Action: "update", Resource: document("acme", "draft")}, true},
+		{"other tenant", Request{Subject: editor, Action: "update", Resource: document("other", "draft")}, false},
+		{"admin inherits editor rules", Request{Subject: security.UserClaims{Role: "admin", TenantID: "acme"}, Action: "update", Resource: document("acme", "draft")}, true},
+		{"admin permission", Request{Subject: admin, Action: "delete", Resource: document("other", "draft")}, true},
+		{"deny fails closed on missing attribute", Request{Subject: admin, Action: "delete", Resource: document("other", "")}, false},
+		{"multiple roles", Request{Subject: security.UserClaims{UserID: "u4"}, Roles: []string{"guest", "viewer"}, Action: "read", Resource: document("acme", "")}, true},
+		{"subject permission", Request{Subject: security.UserClaims{UserID: "u5", Permissions: []string{"documents:read"}}, Action: "read", Resource: document("acme", "")}, true},
+		{"deny wins", Request{Subject: admin, Action: "delete", Resource: document("other", "archived")}, false},
+		{"missing tenant", Request{Subject: security.UserClaims{Role: "editor"}, Action: "update", Resource: document("", "")}, false},
+		{"no role", Request{Subject: security.UserClaims{UserID: "u3"}, Action: "read", Resource: document("acme", "")}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := service.Evaluate(tt.request)
+			assert.Equal(t, tt.allowed, decision.Allowed, decision.Reason)
+		})
+	}
+}
+
+func TestEvaluate_DenyNotEqualsOnMissingAttribute(t *testing.T) {
+	service, err := NewManager(Config{
+		Roles: []RoleConfig{{Name: "member", Permissions: []string{"reports:read"}}},
+		Rules: []RuleConfig{{
+			ID:         "other-tenants",
+			Effect:     EffectDeny,
+			Resources:  []string{"reports"},
+			Conditions: []ConditionConfig{{Attribute: "subject.tenant_id", Operator: OperatorNotEquals, Value: "acme"}},
+		}},
+	})
+	require.NoError(t, err)
+
+	request := Request{Subject: security.UserClaims{Role: "member", TenantID: "acme"}, Action: "read", Resource: Resource{Type: "reports"}}
+	assert.True(t, service.Evaluate(request).Allowed)
+
+	request.Subject.TenantID = "globex"
+	assert.False(t, service.Evaluate(request).Allowed)
+
+	// Without a tenant the deny rule still applies
+	request.Subject.TenantID = ""
+	decision := service.Evaluate(request)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, "other-tenants", decision.RuleID)
+}
+
+func TestExplain(t *testing.T) {
+	service := newTestService(t)
+
+	decision := service.Explain(Request{
+		Subject:  security.UserClaims{Role: "editor", TenantID: "acme"},
+		Action:   "update",
+		Resource: document("other", "draft"),
+	})
+	assert.False(t, decision.Allowed)
+	require.NotEmpty(t, decision.Trace)
+	assert.Equal(t, TraceEntry{Step: "roles", Matched: true, Detail: "editor, viewer"}, decision.Trace[0])
+	assert.Contains(t, decision.Trace, TraceEntry{
+		Step:   "rule editors-update-own-tenant",
+		Detail: "condition resource.tenant_id eq failed",
+	})
+
+	assert.Empty(t, service.Evaluate(Request{Subject: security.UserClaims{Role: "editor"}}).Trace)
+}
+
+func TestNewManager_InvalidPolicy(t *testing.T) {
+	_, err := NewManager(Config{Roles: []RoleConfig{{Name: "a", Inherits: []string{"b"}}, {Name: "b", Inherits: []string{"a"}}}})
+	assert.ErrorIs(t, err, ErrRoleCycle)
+
+	_, err = NewManager(Config{Roles: []RoleConfig{{Name: "a", Inherits: []string{"missing"}}}})
+	assert.ErrorIs(t, err, ErrUnknownRole)
+
+	_, err = NewManager(Config{Rules: []RuleConfig{{ID: "r", Effect: "maybe"}}})
+	assert.ErrorIs(t, err, ErrInvalidEffect)
+
+	_, err = NewManager(Config{Rules: []RuleConfig{{ID: "r", Effect: EffectAllow, Conditions: []ConditionConfig{{Attribute: "user.id", Operator: OperatorEquals}}}}})
+	assert.ErrorIs(t, err, ErrInvalidAttribute)
+}
+
+func TestMiddleware(t *testing.T) {
+	service := newTestService(t)
+
+	serve := func(claims *jwt.Claims, tenantID string) int {
+		e := echo.New()
+		e.PUT("/documents/:id", func(c echo.Context) error {
+			return c.NoContent(http.StatusNoContent)
+		}, func(next echo.HandlerFunc) echo.HandlerFunc {
+			return func(c echo.Context) error {
+				c.Set(middleware.ClaimsContextKey, claims)
+				return next(c)
+			}
+		}, service.Middleware("update", func(c echo.Context) (Resource, error) {
+			return document(tenantID, "draft"), nil
+		}))
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/documents/doc-1", nil))
+		return rec.Code
+	}
+
+	claims := &jwt.Claims{
+		UserID:   "u1",
+		Metadata: map[string]interface{}{"role": "editor", "tenant_id": "acme"},
+	}
+	assert.Equal(t, http.StatusNoContent, serve(claims, "acme"))
+	assert.Equal(t, http.StatusForbidden, serve(claims, "other"))
+}
@@ -0,0 +1,88 @@
+package authz
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/upnext-fng/fulcrum/security"
+	"github.com/upnext-fng/fulcrum/security/middleware"
+)
+
+// DecisionContextKey is where Middleware stores the allowing Decision
+const DecisionContextKey = "authz_decision"
+
+// DeniedResponse is the body of a request denied by the policy
+type DeniedResponse struct {
+	Message string       `json:"message"`
+	Error   string       `json:"error"`
+	Reason  string       `json:"reason"`
+	RuleID  string       `json:"rule_id,omitempty"`
+	Trace   []TraceEntry `json:"trace,omitempty"`
+}
+
+func (m *manager) Middleware(action string, resolve ResourceResolver) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims, ok := middleware.ClaimsFrom(c)
+			if !ok {
+				return echo.NewHTTPError(http.StatusUnauthorized, "missing authorization token")
+			}
+
+			subject, err := security.UserClaimsFromJWT(claims)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, "invalid token claims")
+			}
+			if len(subject.Permissions) == 0 {
+				subject.Permissions = middleware.ClaimsPermissions(claims)
+			}
+
+			var resource Resource
+			if resolve != nil {
+				if resource, err = resolve(c); err != nil {
+					return err
+				}
+			}
+
+			request := Request{
+				Subject:  subject,
+				Roles:    middleware.ClaimsRoles(claims),
+				Action:   action,
+				Resource: resource,
+				Environment: map[string]interface{}{
+					"ip":     c.RealIP(),
+					"method": c.Request().Method,
+					"path":   c.Path(),
+					"time":   time.Now().UTC().Format(time.RFC3339),
+				},
+			}
+
+			var decision Decision
+			if m.config.Explain {
+				decision = m.Explain(request)
+			} else {
+				decision = m.Evaluate(request)
+			}
+
+			if !decision.Allowed {
+				return echo.NewHTTPError(http.StatusForbidden, DeniedResponse{
+					Message: "forbidden",
+					Error:   "forbidden",
+					Reason:  decision.Reason,
+					RuleID:  decision.RuleID,
+					Trace:   decision.Trace,
+				})
+			}
+
+			c.Set(DecisionContextKey, decision)
+			return next(c)
+		}
+	}
+}
+
+// ResourceType resolves a resource by type, taking its ID from a path param
+func ResourceType(resourceType, idParam string) ResourceResolver {
+	return func(c echo.Context) (Resource, error) {
+		return Resource{Type: resourceType, ID: c.Param(idParam)}, nil
+	}
+}
@@ -0,0 +1,27 @@
+package authz
+
+import "github.com/upnext-fng/fulcrum/configuration"
+
+func NewService(config Config) (Service, error) {
+	return NewManager(config)
+}
+
+// ServiceProvider loads the policy from the "authz" configuration section
+func ServiceProvider(configService configuration.ConfigurationService) (Service, error) {
+	config, err := LoadConfig(configService)
+	if err != nil {
+		return nil, err
+	}
+	return NewManager(config)
+}
+
+// LoadConfig reads the "authz" configuration section
+func LoadConfig(configService configuration.ConfigurationService) (Config, error) {
+	var target struct {
+		Authz Config `mapstructure:"authz"`
+	}
+	if err := configService.LoadConfig(&target); err != nil {
+		return Config{}, err
+	}
+	return target.Authz, nil
+}
@@ -0,0 +1,67 @@
+package authz
+
+import (
+	"errors"
+
+	"github.com/upnext-fng/fulcrum/security"
+)
+
+type Effect string
+
+const (
+	EffectAllow Effect = "allow"
+	EffectDeny  Effect = "deny"
+)
+
+type Operator string
+
+const (
+	OperatorEquals    Operator = "eq"
+	OperatorNotEquals Operator = "ne"
+	OperatorIn        Operator = "in"
+	OperatorNotIn     Operator = "not_in"
+	OperatorContains  Operator = "contains"
+	OperatorExists    Operator = "exists"
+)
+
+// Request asks whether Subject may perform Action on Resource. Roles adds
+// to Subject.Role for multi-role tokens; Subject.Permissions grant directly,
+// like permissions declared on a role.
+type Request struct {
+	Subject     security.UserClaims
+	Roles       []string
+	Action      string
+	Resource    Resource
+	Environment map[string]interface{}
+}
+
+// Resource is the object of a request. Attributes are matched by conditions
+// as "resource.<name>".
+type Resource struct {
+	Type       string
+	ID         string
+	Attributes map[string]interface{}
+}
+
+// Decision is the outcome of an evaluation. Trace is only filled by Explain.
+type Decision struct {
+	Allowed bool         `json:"allowed"`
+	Reason  string       `json:"reason"`
+	RuleID  string       `json:"rule_id,omitempty"`
+	Trace   []TraceEntry `json:"trace,omitempty"`
+}
+
+// TraceEntry records one step of an evaluation
+type TraceEntry struct {
+	Step    string `json:"step"`
+	Matched bool   `json:"matched"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+var (
+	ErrUnknownRole      = errors.New("unknown role")
+	ErrRoleCycle        = errors.New("role inheritance cycle")
+	ErrInvalidEffect    = errors.New("invalid rule effect")
+	ErrInvalidOperator  = errors.New("invalid condition operator")
+	ErrInvalidAttribute = errors.New("invalid condition attribute")
+)
@@ -7,8 +7,11 @@ import (
 	"go.uber.org/fx"
 )
 
+// Module provides the facade together with the jwt service it uses, built
+// from Config.JWT, so that session, oauth2 and apikey share its stores
 var Module = fx.Options(
 	fx.Provide(NewSecurityService),
+	fx.Provide(func(config Config) jwt.Config { return config.JWT }),
 	jwt.Module,
 	password.Module,
 	middleware.Module,
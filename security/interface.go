@@ -2,6 +2,7 @@ package security
 
 import (
 	"github.com/labstack/echo/v4"
+	"github.com/upnext-fng/fulcrum/security/password"
 )
 
 type SecurityService interface {
@@ -9,11 +10,23 @@ type SecurityService interface {
 	GenerateToken(request TokenRequest) (TokenResponse, error)
 	ValidateToken(request ValidationRequest) (ValidationResponse, error)
 	RefreshAccessToken(refreshToken string) (TokenResponse, error)
+	JWKSHandler() echo.HandlerFunc
+
+	// Cookie authentication, see middleware.CookieConfig
+	IssueTokenCookies(c echo.Context, request TokenRequest) (TokenResponse, error)
+	RefreshTokenCookies(c echo.Context) (TokenResponse, error)
+	ClearTokenCookies(c echo.Context)
 
 	// Password operations
 	HashPassword(password string) (string, error)
 	VerifyPassword(hashedPassword, password string) error
+	// VerifyAndRehashPassword also returns a new hash to store when the
+	// stored one uses outdated parameters
+	VerifyAndRehashPassword(hashedPassword, password string) (string, error)
 	ValidatePassword(password string) error
+	ValidatePasswordFor(password string, context password.PolicyContext) error
+	ChangePassword(userID, password string, context password.PolicyContext) (string, error)
+	PasswordExpiry(userID string) (*password.Expiry, error)
 
 	// Middleware
 	JWTMiddleware() echo.MiddlewareFunc
@@ -1,6 +1,10 @@
 package jwt
 
-import "github.com/golang-jwt/jwt/v5"
+import (
+	"encoding/json"
+
+	"github.com/golang-jwt/jwt/v5"
+)
 
 type claimsParser struct {
 	config Config
@@ -26,7 +30,22 @@ func (p *claimsParser) ParseClaims(token *jwt.Token) (*Claims, error) {
 		return nil, ErrInvalidClaims
 	}
 
-	return p.ExtractClaims(claims)
+	parsedClaims, err := p.ExtractClaims(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read the typed extension from the raw payload so numbers keep their
+	// exact representation
+	if token.Raw != "" && parsedClaims.Extension != nil {
+		extension, err := rawExtension(token.Raw)
+		if err != nil {
+			return nil, err
+		}
+		parsedClaims.Extension = extension
+	}
+
+	return parsedClaims, nil
 }
 
 func (p *claimsParser) ExtractClaims(claims jwt.MapClaims) (*Claims, error) {
@@ -36,13 +55,15 @@ func (p *claimsParser) ExtractClaims(claims jwt.MapClaims) (*Claims, error) {
 	}
 
 	parsedClaims := &Claims{
+		ID:        p.getStringClaim(claims, "jti"),
+		FamilyID:  p.getStringClaim(claims, "fid"),
 		UserID:    userID,
 		TokenType: p.getStringClaim(claims, "token_type"),
 		ClientID:  p.getStringClaim(claims, "client_id"),
 		DeviceID:  p.getStringClaim(claims, "device_id"),
 		SessionID: p.getStringClaim(claims, "session_id"),
 		Issuer:    p.getStringClaim(claims, "iss"),
-		Audience:  p.getStringClaim(claims, "aud"),
+		Audiences: p.getAudienceClaim(claims),
 		Subject:   p.getStringClaim(claims, "sub"),
 		ExpiresAt: p.getInt64Claim(claims, "exp"),
 		IssuedAt:  p.getInt64Claim(claims, "iat"),
@@ -50,11 +71,30 @@ func (p *claimsParser) ExtractClaims(claims jwt.MapClaims) (*Claims, error) {
 		Scopes:    p.getStringArrayClaim(claims, "scopes"),
 		Metadata:  p.getMapClaim(claims, "metadata"),
 		Custom:    p.getCustomClaims(claims),
+		Extension: p.getRawClaim(claims, "ext"),
+	}
+
+	if len(parsedClaims.Audiences) > 0 {
+		parsedClaims.Audience = parsedClaims.Audiences[0]
 	}
 
 	return parsedClaims, nil
 }
 
+// getAudienceClaim reads aud, which RFC 7519 allows as a string or an array
+func (p *claimsParser) getAudienceClaim(claims jwt.MapClaims) []string {
+	switch aud := claims["aud"].(type) {
+	case string:
+		if aud == "" {
+			return nil
+		}
+		return []string{aud}
+	case []string:
+		return aud
+	}
+	return p.getStringArrayClaim(claims, "aud")
+}
+
 func (p *claimsParser) getStringClaim(claims jwt.MapClaims, key string) string {
 	if value, ok := claims[key]; ok {
 		if str, ok := value.(string); ok {
@@ -102,26 +142,45 @@ func (p *claimsParser) getMapClaim(claims jwt.MapClaims, key string) map[string]
 	return nil
 }
 
-func (p *claimsParser) getCustomClaims(claims jwt.MapClaims) map[string]interface{} {
-	protectedKeys := map[string]bool{
-		"user_id":    true,
-		"token_type": true,
-		"client_id":  true,
-		"device_id":  true,
-		"session_id": true,
-		"scopes":     true,
-		"metadata":   true,
-		"iss":        true,
-		"aud":        true,
-		"sub":        true,
-		"exp":        true,
-		"iat":        true,
-		"nbf":        true,
+func (p *claimsParser) getRawClaim(claims jwt.MapClaims, key string) json.RawMessage {
+	value, ok := claims[key]
+	if !ok || value == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil
 	}
+	return raw
+}
 
+// reservedClaims are set by the generator; custom claims never override
+// them and are never read back from them
+var reservedClaims = map[string]bool{
+	"jti":        true,
+	"fid":        true,
+	"user_id":    true,
+	"token_type": true,
+	"client_id":  true,
+	"device_id":  true,
+	"session_id": true,
+	"scopes":     true,
+	"scope":      true,
+	"metadata":   true,
+	"iss":        true,
+	"aud":        true,
+	"sub":        true,
+	"exp":        true,
+	"iat":        true,
+	"nbf":        true,
+	"ext":        true,
+}
+
+func (p *claimsParser) getCustomClaims(claims jwt.MapClaims) map[string]interface{} {
 	customClaims := make(map[string]interface{})
 	for key, value := range claims {
-		if !protectedKeys[key] {
+		if !reservedClaims[key] {
 			customClaims[key] = value
 		}
 	}
@@ -0,0 +1,29 @@
+package jwt
+
+import "time"
+
+// Clock tells the current time. Inject one through Config.Clock to control
+// time in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function to Clock
+type ClockFunc func() time.Time
+
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock returns the wall clock
+func SystemClock() Clock {
+	return ClockFunc(time.Now)
+}
+
+// now returns the time according to the configured clock
+func (c Config) now() time.Time {
+	if c.Clock == nil {
+		return time.Now()
+	}
+	return c.Clock.Now()
+}
@@ -8,4 +8,67 @@ type Config struct {
 	RefreshTokenTTL time.Duration `mapstructure:"refresh_token_ttl"`
 	Issuer          string        `mapstructure:"jwt_issuer"`
 	Audience        string        `mapstructure:"jwt_audience"`
+
+	// Leeway tolerates clock drift between nodes when checking exp and nbf.
+	// Clock defaults to the system clock.
+	Leeway time.Duration `mapstructure:"leeway"`
+	Clock  Clock         `mapstructure:"-"`
+
+	// Asymmetric signing. SigningMethod defaults to HS256, which uses Secret.
+	// For RS*, PS*, ES* and EdDSA the keys are PEM encoded, either inline or
+	// read from a file. A verifier-only service only needs the public key.
+	SigningMethod  string `mapstructure:"signing_method"`
+	PrivateKey     string `mapstructure:"private_key"`
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+	PublicKey      string `mapstructure:"public_key"`
+	PublicKeyFile  string `mapstructure:"public_key_file"`
+	KeyID          string `mapstructure:"key_id"`
+
+	// Keys configures a keyring for rotation and replaces the single key above
+	// for signing. While migrating, keep the single key configured so the
+	// tokens it signed, which have no kid unless KeyID was set, still verify;
+	// remove it once they have expired. Keyring overrides both and is shared
+	// by the generator and validator.
+	Keys    []KeyConfig `mapstructure:"keys"`
+	Keyring Keyring     `mapstructure:"-"`
+
+	// Remote verification. When JWKSURL is set tokens are verified against the
+	// published key set of another issuer instead of local keys.
+	JWKSURL                string        `mapstructure:"jwks_url"`
+	JWKSCacheTTL           time.Duration `mapstructure:"jwks_cache_ttl"`
+	JWKSMinRefreshInterval time.Duration `mapstructure:"jwks_min_refresh_interval"`
+
+	// Refresh token rotation. Every refresh returns a new token pair and a
+	// reused refresh token revokes its whole family. The store defaults to an
+	// in-memory one, which only works for a single instance.
+	RefreshTokenRotation bool              `mapstructure:"refresh_token_rotation"`
+	RefreshTokenStore    RefreshTokenStore `mapstructure:"-"`
+
+	// RevocationStore is consulted on every validation. NewJWTService defaults
+	// it to an in-memory store.
+	RevocationStore RevocationStore `mapstructure:"-"`
+}
+
+// KeyConfig describes one key of a rotating keyring. A key signs new tokens
+// once ActivatesAt has passed and verifies tokens until RetiresAt.
+type KeyConfig struct {
+	ID             string    `mapstructure:"id"`
+	SigningMethod  string    `mapstructure:"signing_method"`
+	Secret         string    `mapstructure:"secret"`
+	PrivateKey     string    `mapstructure:"private_key"`
+	PrivateKeyFile string    `mapstructure:"private_key_file"`
+	PublicKey      string    `mapstructure:"public_key"`
+	PublicKeyFile  string    `mapstructure:"public_key_file"`
+	ActivatesAt    time.Time `mapstructure:"activates_at"`
+	RetiresAt      time.Time `mapstructure:"retires_at"`
+}
+
+// hasSingleKey reports whether the single key above is configured
+func (c Config) hasSingleKey() bool {
+	return c.Secret != "" || c.PrivateKey != "" || c.PrivateKeyFile != "" ||
+		c.PublicKey != "" || c.PublicKeyFile != ""
+}
+
+func (c Config) rotatesRefreshTokens() bool {
+	return c.RefreshTokenRotation && c.RefreshTokenStore != nil
 }
@@ -3,6 +3,8 @@ package jwt
 import (
 	"errors"
 	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
 )
 
 var (
@@ -10,11 +12,51 @@ var (
 	ErrTokenExpired     = errors.New("token expired")
 	ErrInvalidSignature = errors.New("invalid signature")
 	ErrInvalidClaims    = errors.New("invalid claims")
+
+	ErrTokenNotYetValid  = errors.New("token not yet valid")
+	ErrInvalidIssuer     = errors.New("invalid issuer")
+	ErrInvalidAudience   = errors.New("invalid audience")
+	ErrInsufficientScope = errors.New("insufficient scope")
+
+	ErrUnsupportedSigningMethod = errors.New("unsupported signing method")
+	ErrInvalidKey               = errors.New("invalid key")
+	ErrMissingSigningKey        = errors.New("missing signing key")
+	ErrMissingVerificationKey   = errors.New("missing verification key")
+	ErrUnknownKeyID             = errors.New("unknown key id")
+	ErrNoActiveKey              = errors.New("no active signing key")
+
+	ErrTokenRevoked          = errors.New("token revoked")
+	ErrRefreshTokenReused    = errors.New("refresh token reused")
+	ErrRefreshTokenNotFound  = errors.New("refresh token not found")
+	ErrRefreshRotationActive = errors.New("refresh token rotation is enabled, use RefreshTokenPair")
+
+	ErrMissingTypedClaims = errors.New("missing typed claims")
 )
 
+// ErrorCode is a machine-readable reason for a failed validation
+type ErrorCode string
+
+const (
+	CodeTokenMalformed   ErrorCode = "token_malformed"
+	CodeInvalidSignature ErrorCode = "invalid_signature"
+	CodeUnknownKey       ErrorCode = "unknown_kid"
+	CodeTokenExpired     ErrorCode = "token_expired"
+	CodeTokenNotYetValid ErrorCode = "token_not_yet_valid"
+	CodeIssuerMismatch   ErrorCode = "iss_mismatch"
+	CodeAudienceMismatch ErrorCode = "aud_mismatch"
+	CodeScopeMissing     ErrorCode = "scope_missing"
+	CodeTokenRevoked     ErrorCode = "token_revoked"
+	CodeInvalidClaims    ErrorCode = "invalid_claims"
+)
+
+// ValidationError describes why a token or claim was rejected. Err holds the
+// sentinel error, so errors.Is keeps working; Missing lists the scopes a
+// token lacked for CodeScopeMissing.
 type ValidationError struct {
+	Code    ErrorCode
 	Field   string
 	Message string
+	Missing []string
 	Err     error
 }
 
@@ -28,8 +70,41 @@ func (e *ValidationError) Unwrap() error {
 
 func NewValidationError(field, message string, err error) *ValidationError {
 	return &ValidationError{
+		Code:    CodeInvalidClaims,
 		Field:   field,
 		Message: message,
 		Err:     err,
 	}
 }
+
+// ErrorCodeOf returns the reason code carried by err, if any
+func ErrorCodeOf(err error) ErrorCode {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Code
+	}
+	return ""
+}
+
+func newClaimError(code ErrorCode, field, message string, err error) *ValidationError {
+	return &ValidationError{
+		Code:    code,
+		Field:   field,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// newParseError classifies errors returned while parsing and verifying a token
+func newParseError(err error) *ValidationError {
+	switch {
+	case errors.Is(err, ErrUnknownKeyID):
+		return newClaimError(CodeUnknownKey, "kid", "unknown signing key", err)
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, ErrInvalidSignature):
+		return newClaimError(CodeInvalidSignature, "signature", "signature is invalid", err)
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return newClaimError(CodeTokenMalformed, "token", "token is malformed", err)
+	default:
+		return newClaimError(CodeInvalidSignature, "token", err.Error(), err)
+	}
+}
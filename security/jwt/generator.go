@@ -1,7 +1,8 @@
 package jwt
 
 import (
-	"time"
+	"crypto/rand"
+	"encoding/hex"
 
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -9,6 +10,7 @@ import (
 type tokenGenerator struct {
 	config       Config
 	claimsParser ClaimsParser
+	keyring      Keyring
 }
 
 func TokenGeneratorProvider(config Config, parser ClaimsParser) TokenGenerator {
@@ -19,14 +21,16 @@ func NewTokenGenerator(config Config, parser ClaimsParser) TokenGenerator {
 	return &tokenGenerator{
 		config:       config,
 		claimsParser: parser,
+		keyring:      KeyringProvider(config),
 	}
 }
 
 func (g *tokenGenerator) GenerateAccessToken(claims Claims, opts ...TokenOption) (*SignedToken, error) {
+	now := g.config.now()
 	config := &TokenConfig{
 		TokenKind: AccessTokenType,
-		IssuedAt:  time.Now(),
-		ExpiresAt: time.Now().Add(g.config.AccessTokenTTL),
+		IssuedAt:  now,
+		ExpiresAt: now.Add(g.config.AccessTokenTTL),
 	}
 
 	// Apply options
@@ -40,10 +44,11 @@ func (g *tokenGenerator) GenerateAccessToken(claims Claims, opts ...TokenOption)
 }
 
 func (g *tokenGenerator) GenerateRefreshToken(claims Claims, opts ...RefreshOption) (*SignedToken, error) {
+	now := g.config.now()
 	config := &TokenConfig{
 		TokenKind: RefreshTokenType,
-		IssuedAt:  time.Now(),
-		ExpiresAt: time.Now().Add(g.config.RefreshTokenTTL),
+		IssuedAt:  now,
+		ExpiresAt: now.Add(g.config.RefreshTokenTTL),
 	}
 
 	// Apply options
@@ -53,7 +58,33 @@ func (g *tokenGenerator) GenerateRefreshToken(claims Claims, opts ...RefreshOpti
 		}
 	}
 
-	return g.generateSignedToken(claims, config)
+	if !g.config.rotatesRefreshTokens() {
+		return g.generateSignedToken(claims, config)
+	}
+
+	// Rotated refresh tokens are tracked individually and grouped in families
+	claims.ID = newTokenID()
+	if claims.FamilyID == "" {
+		claims.FamilyID = newTokenID()
+	}
+
+	token, err := g.generateSignedToken(claims, config)
+	if err != nil {
+		return nil, err
+	}
+
+	err = g.config.RefreshTokenStore.Save(&RefreshTokenRecord{
+		ID:        claims.ID,
+		FamilyID:  claims.FamilyID,
+		UserID:    claims.UserID,
+		IssuedAt:  config.IssuedAt,
+		ExpiresAt: config.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
 }
 
 func (g *tokenGenerator) GenerateTokenPair(claims Claims, opts ...TokenPairOption) (*TokenPair, error) {
@@ -89,7 +120,19 @@ func (g *tokenGenerator) GenerateTokenPair(claims Claims, opts ...TokenPairOptio
 }
 
 func (g *tokenGenerator) generateSignedToken(claims Claims, config *TokenConfig) (*SignedToken, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
+	key, err := g.keyring.SigningKey()
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.ID == "" {
+		claims.ID = newTokenID()
+	}
+
+	token := jwt.New(key.Method)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
 	tokenClaims := token.Claims.(jwt.MapClaims)
 
 	// Set standard claims
@@ -98,8 +141,12 @@ func (g *tokenGenerator) generateSignedToken(claims Claims, config *TokenConfig)
 	tokenClaims["exp"] = config.ExpiresAt.Unix()
 	tokenClaims["iat"] = config.IssuedAt.Unix()
 	tokenClaims["nbf"] = config.IssuedAt.Unix()
+	tokenClaims["jti"] = claims.ID
 
 	// Set optional claims
+	if claims.FamilyID != "" {
+		tokenClaims["fid"] = claims.FamilyID
+	}
 	if claims.ClientID != "" {
 		tokenClaims["client_id"] = claims.ClientID
 	}
@@ -122,6 +169,8 @@ func (g *tokenGenerator) generateSignedToken(claims Claims, config *TokenConfig)
 	}
 	if len(config.Audience) > 0 {
 		tokenClaims["aud"] = config.Audience
+	} else if len(claims.Audiences) > 0 {
+		tokenClaims["aud"] = claims.Audiences
 	} else if claims.Audience != "" {
 		tokenClaims["aud"] = claims.Audience
 	} else if g.config.Audience != "" {
@@ -140,11 +189,16 @@ func (g *tokenGenerator) generateSignedToken(claims Claims, config *TokenConfig)
 
 	// Add custom claims
 	for key, value := range claims.Custom {
-		tokenClaims[key] = value
+		if !reservedClaims[key] {
+			tokenClaims[key] = value
+		}
+	}
+	if len(claims.Extension) > 0 {
+		tokenClaims["ext"] = claims.Extension
 	}
 
 	// Sign token
-	tokenString, err := token.SignedString([]byte(g.config.Secret))
+	tokenString, err := token.SignedString(key.SigningKey)
 	if err != nil {
 		return nil, err
 	}
@@ -155,7 +209,17 @@ func (g *tokenGenerator) generateSignedToken(claims Claims, config *TokenConfig)
 		IssuedAt:  config.IssuedAt,
 		TokenType: "Bearer",
 		ExpiresIn: int64(config.ExpiresAt.Sub(config.IssuedAt).Seconds()),
+		id:        claims.ID,
 		tokenKind: config.TokenKind,
 		scope:     config.Scope,
 	}, nil
 }
+
+// newTokenID returns a random identifier suitable for the jti claim
+func newTokenID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -1,6 +1,10 @@
 package jwt
 
-import "github.com/golang-jwt/jwt/v5"
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
 
 // ClaimsParser Component interfaces
 type ClaimsParser interface {
@@ -8,6 +12,13 @@ type ClaimsParser interface {
 	ExtractClaims(claims jwt.MapClaims) (*Claims, error)
 }
 
+// Keyring holds the keys used to sign and verify tokens
+type Keyring interface {
+	SigningKey() (*Key, error)
+	VerificationKey(kid string) (*Key, error)
+	VerificationKeys() ([]*Key, error)
+}
+
 type TokenGenerator interface {
 	GenerateAccessToken(claims Claims, opts ...TokenOption) (*SignedToken, error)
 	GenerateRefreshToken(claims Claims, opts ...RefreshOption) (*SignedToken, error)
@@ -21,6 +32,34 @@ type TokenValidator interface {
 
 type TokenRefresher interface {
 	RefreshAccessToken(refreshToken string, opts ...RefreshOption) (*SignedToken, error)
+	RefreshTokenPair(refreshToken string, opts ...TokenPairOption) (*TokenPair, error)
+}
+
+// RefreshTokenStore persists issued refresh tokens for rotation.
+// MarkUsed must be atomic and return ErrRefreshTokenReused when the token
+// was already used.
+type RefreshTokenStore interface {
+	Save(record *RefreshTokenRecord) error
+	Find(id string) (*RefreshTokenRecord, error)
+	MarkUsed(id string, usedAt time.Time) error
+	RevokeFamily(familyID string, revokedAt time.Time) error
+}
+
+// TokenRevoker invalidates tokens before they expire
+type TokenRevoker interface {
+	Revoke(jti string) error
+	RevokeSession(sessionID string) error
+	RevokeUser(userID string, before time.Time) error
+}
+
+// RevocationStore records revoked tokens, sessions and users. Entries only
+// need to be kept until expiresAt, after which the tokens they cover have
+// expired on their own.
+type RevocationStore interface {
+	RevokeToken(jti string, expiresAt time.Time) error
+	RevokeSession(sessionID string, expiresAt time.Time) error
+	RevokeUser(userID string, before time.Time, expiresAt time.Time) error
+	IsRevoked(claims *Claims) (bool, error)
 }
 
 // Service Main service interface
@@ -28,4 +67,6 @@ type Service interface {
 	TokenGenerator
 	TokenValidator
 	TokenRefresher
+	TokenRevoker
+	Keyring() Keyring
 }
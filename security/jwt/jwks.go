@@ -0,0 +1,219 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// JWKSPath is the conventional location of the published key set
+const JWKSPath = "/.well-known/jwks.json"
+
+// JSONWebKey is the public half of a key in RFC 7517 form
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// NewJSONWebKeySet publishes every asymmetric verification key of the keyring.
+// HMAC keys are shared secrets and are never included.
+func NewJSONWebKeySet(keyring Keyring) (*JSONWebKeySet, error) {
+	keys, err := keyring.VerificationKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	set := &JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(keys))}
+	for _, key := range keys {
+		if _, ok := key.Method.(*jwt.SigningMethodHMAC); ok {
+			continue
+		}
+
+		jwk, err := NewJSONWebKey(key)
+		if err != nil {
+			return nil, err
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set, nil
+}
+
+// NewJSONWebKey encodes the verification key of key
+func NewJSONWebKey(key *Key) (JSONWebKey, error) {
+	jwk := JSONWebKey{
+		Use: "sig",
+		Kid: key.ID,
+		Alg: key.Method.Alg(),
+	}
+
+	switch publicKey := key.VerificationKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = encodeSegment(publicKey.N.Bytes())
+		jwk.E = encodeSegment(big.NewInt(int64(publicKey.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = publicKey.Curve.Params().Name
+		jwk.X = encodeSegment(publicKey.X.FillBytes(make([]byte, size)))
+		jwk.Y = encodeSegment(publicKey.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = encodeSegment(publicKey)
+	default:
+		return JSONWebKey{}, fmt.Errorf("%w: cannot publish %T", ErrInvalidKey, key.VerificationKey)
+	}
+
+	return jwk, nil
+}
+
+// Key decodes the JWK into a verify-only key
+func (k JSONWebKey) Key() (*Key, error) {
+	key := &Key{ID: k.Kid}
+
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeSegment(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeSegment(k.E)
+		if err != nil {
+			return nil, err
+		}
+		key.VerificationKey = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+		key.Method = jwt.SigningMethodRS256
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+			key.Method = jwt.SigningMethodES256
+		case "P-384":
+			curve = elliptic.P384()
+			key.Method = jwt.SigningMethodES384
+		case "P-521":
+			curve = elliptic.P521()
+			key.Method = jwt.SigningMethodES512
+		default:
+			return nil, fmt.Errorf("%w: unsupported curve %q", ErrInvalidKey, k.Crv)
+		}
+		x, err := decodeSegment(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeSegment(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		publicKey := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(publicKey.X, publicKey.Y) {
+			return nil, fmt.Errorf("%w: point is not on curve", ErrInvalidKey)
+		}
+		key.VerificationKey = publicKey
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("%w: unsupported curve %q", ErrInvalidKey, k.Crv)
+		}
+		x, err := decodeSegment(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("%w: bad Ed25519 key size", ErrInvalidKey)
+		}
+		key.VerificationKey = ed25519.PublicKey(x)
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("%w: unsupported key type %q", ErrInvalidKey, k.Kty)
+	}
+
+	if k.Alg != "" {
+		method, err := k.signingMethod(key.Method)
+		if err != nil {
+			return nil, err
+		}
+		key.Method = method
+	}
+
+	return key, nil
+}
+
+// JWKSHandler serves the keyring's public keys as a JWK set
+func JWKSHandler(keyring Keyring) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		set, err := NewJSONWebKeySet(keyring)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to load keys")
+		}
+
+		c.Response().Header().Set("Cache-Control", "public, max-age=300")
+		return c.JSON(http.StatusOK, set)
+	}
+}
+
+// JWKSRoutes registers the JWKS endpoint; it satisfies http.RouteRegistrar
+type JWKSRoutes struct {
+	Keyring Keyring
+}
+
+func (r JWKSRoutes) RegisterRoutes(e *echo.Echo) {
+	e.GET(JWKSPath, JWKSHandler(r.Keyring))
+}
+
+// signingMethod resolves the alg of the JWK, which must suit its key type.
+// Keys without alg use the default method of their type and curve.
+func (k JSONWebKey) signingMethod(defaultMethod jwt.SigningMethod) (jwt.SigningMethod, error) {
+	method := jwt.GetSigningMethod(k.Alg)
+
+	var compatible bool
+	switch method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		compatible = k.Kty == "RSA"
+	case *jwt.SigningMethodECDSA:
+		// Each ECDSA alg is bound to one curve
+		compatible = method == defaultMethod
+	case *jwt.SigningMethodEd25519:
+		compatible = k.Kty == "OKP"
+	}
+
+	if !compatible {
+		return nil, fmt.Errorf("%w: alg %q does not match key type %q", ErrInvalidKey, k.Alg, k.Kty)
+	}
+	return method, nil
+}
+
+func encodeSegment(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSegment(segment string) ([]byte, error) {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,178 @@
+package jwt
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newJWKSServer(t *testing.T, keyring *Keyring, fetches *int32) *httptest.Server {
+	t.Helper()
+
+	e := echo.New()
+	e.GET(JWKSPath, func(c echo.Context) error {
+		atomic.AddInt32(fetches, 1)
+		return JWKSHandler(*keyring)(c)
+	})
+
+	server := httptest.NewServer(e)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestJWKSHandler_PublishesPublicKeysOnly(t *testing.T) {
+	rsaPrivate, _ := generatePEMKeyPair(t, "RS256")
+	ecPrivate, _ := generatePEMKeyPair(t, "ES256")
+	edPrivate, _ := generatePEMKeyPair(t, "EdDSA")
+
+	keyring := KeyringProvider(Config{Keys: []KeyConfig{
+		{ID: "rsa", SigningMethod: "RS256", PrivateKey: rsaPrivate},
+		{ID: "ec", SigningMethod: "ES256", PrivateKey: ecPrivate},
+		{ID: "ed", SigningMethod: "EdDSA", PrivateKey: edPrivate},
+		{ID: "hmac", Secret: "never-published"},
+	}})
+
+	var fetches int32
+	server := newJWKSServer(t, &keyring, &fetches)
+
+	resp, err := http.Get(server.URL + JWKSPath)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var set JSONWebKeySet
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&set))
+	require.Len(t, set.Keys, 3)
+
+	for _, jwk := range set.Keys {
+		assert.NotEqual(t, "hmac", jwk.Kid)
+		assert.NotEqual(t, "oct", jwk.Kty)
+
+		key, err := jwk.Key()
+		require.NoError(t, err)
+		assert.False(t, key.CanSign())
+	}
+}
+
+func TestRemoteTokenValidator(t *testing.T) {
+	firstPrivate, _ := generatePEMKeyPair(t, "ES256")
+	secondPrivate, _ := generatePEMKeyPair(t, "RS256")
+
+	first := KeyConfig{ID: "first", SigningMethod: "ES256", PrivateKey: firstPrivate, ActivatesAt: time.Now().Add(-time.Hour)}
+	second := KeyConfig{ID: "second", SigningMethod: "RS256", PrivateKey: secondPrivate, ActivatesAt: time.Now().Add(-time.Minute)}
+
+	issuerConfig := Config{AccessTokenTTL: time.Hour, Keys: []KeyConfig{first}}
+	issuer := NewJWTService(issuerConfig)
+	keyring := issuer.Keyring()
+
+	var fetches int32
+	server := newJWKSServer(t, &keyring, &fetches)
+
+	validator := NewRemoteTokenValidator(Config{
+		JWKSURL:                server.URL + JWKSPath,
+		JWKSMinRefreshInterval: time.Nanosecond,
+	}, NewClaimsParser(Config{}))
+
+	token, err := issuer.GenerateAccessToken(Claims{UserID: "test-user-123"})
+	require.NoError(t, err)
+
+	validated, err := validator.ValidateToken(token.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "test-user-123", validated.Claims.UserID)
+
+	// Cached keys are reused
+	_, err = validator.ValidateToken(token.Token)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+
+	// The issuer rotates; an unknown kid triggers a refetch
+	issuerConfig.Keys = []KeyConfig{first, second}
+	issuer = NewJWTService(issuerConfig)
+	keyring = issuer.Keyring()
+
+	token, err = issuer.GenerateAccessToken(Claims{UserID: "test-user-123"})
+	require.NoError(t, err)
+
+	validated, err = validator.ValidateToken(token.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "second", validated.Token.Header["kid"])
+	assert.Equal(t, int32(2), atomic.LoadInt32(&fetches))
+}
+
+func TestRemoteTokenValidator_UnknownKid(t *testing.T) {
+	private, _ := generatePEMKeyPair(t, "EdDSA")
+	issuer := NewJWTService(Config{AccessTokenTTL: time.Hour, Keys: []KeyConfig{
+		{ID: "unpublished", SigningMethod: "EdDSA", PrivateKey: private},
+	}})
+
+	keyring := NewKeyring()
+	var fetches int32
+	server := newJWKSServer(t, &keyring, &fetches)
+
+	validator := NewRemoteTokenValidator(Config{
+		JWKSURL:                server.URL + JWKSPath,
+		JWKSMinRefreshInterval: time.Hour,
+	}, NewClaimsParser(Config{}))
+
+	token, err := issuer.GenerateAccessToken(Claims{UserID: "test-user-123"})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = validator.ValidateToken(token.Token)
+		assert.ErrorIs(t, err, ErrUnknownKeyID)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+}
+
+func TestRemoteKeyring_ConcurrentLookupsShareFetch(t *testing.T) {
+	private, _ := generatePEMKeyPair(t, "EdDSA")
+	issuer := NewJWTService(Config{AccessTokenTTL: time.Hour, Keys: []KeyConfig{
+		{ID: "ed", SigningMethod: "EdDSA", PrivateKey: private},
+	}})
+
+	keyring := issuer.Keyring()
+	var fetches int32
+	server := newJWKSServer(t, &keyring, &fetches)
+
+	remote := NewRemoteKeyring(Config{JWKSURL: server.URL + JWKSPath})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key, err := remote.VerificationKey("ed")
+			assert.NoError(t, err)
+			assert.NotNil(t, key)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+}
+
+func TestJSONWebKey_RejectsAlgForOtherKeyType(t *testing.T) {
+	_, public := generatePEMKeyPair(t, "ES256")
+	key, err := NewKeyFromKeyConfig(KeyConfig{ID: "ec", SigningMethod: "ES256", PublicKey: public})
+	require.NoError(t, err)
+
+	jwk, err := NewJSONWebKey(key)
+	require.NoError(t, err)
+
+	decoded, err := jwk.Key()
+	require.NoError(t, err)
+	assert.Equal(t, "ES256", decoded.Method.Alg())
+
+	for _, alg := range []string{"RS256", "ES384", "EdDSA", "HS256", "none"} {
+		jwk.Alg = alg
+		_, err = jwk.Key()
+		assert.ErrorIs(t, err, ErrInvalidKey, alg)
+	}
+}
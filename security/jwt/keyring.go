@@ -0,0 +1,169 @@
+package jwt
+
+import "fmt"
+
+type staticKeyring struct {
+	keys  []*Key
+	clock Clock
+}
+
+// NewKeyring creates a keyring from already loaded keys
+func NewKeyring(keys ...*Key) Keyring {
+	return &staticKeyring{
+		keys:  keys,
+		clock: SystemClock(),
+	}
+}
+
+// withClock makes a static keyring select keys by clock
+func withClock(keyring Keyring, clock Clock) Keyring {
+	if static, ok := keyring.(*staticKeyring); ok && clock != nil {
+		static.clock = clock
+	}
+	return keyring
+}
+
+// NewKeyringFromConfig loads the keyring described by config. Without Keys the
+// keyring holds the single key configured by SigningMethod and friends. With
+// Keys that single key, when still configured, only verifies the tokens it
+// signed, so tokens from before the move to Keys stay valid.
+func NewKeyringFromConfig(config Config) (Keyring, error) {
+	if config.Keyring != nil {
+		return config.Keyring, nil
+	}
+
+	if config.JWKSURL != "" {
+		return NewRemoteKeyring(config), nil
+	}
+
+	if len(config.Keys) == 0 {
+		key, err := NewKeyFromConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		return withClock(NewKeyring(key), config.Clock), nil
+	}
+
+	seen := make(map[string]bool)
+	keys := make([]*Key, 0, len(config.Keys))
+	for _, keyConfig := range config.Keys {
+		if seen[keyConfig.ID] {
+			return nil, fmt.Errorf("%w: duplicate key id %q", ErrInvalidKey, keyConfig.ID)
+		}
+		seen[keyConfig.ID] = true
+
+		key, err := NewKeyFromKeyConfig(keyConfig)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	legacy, err := legacyKey(config, seen)
+	if err != nil {
+		return nil, err
+	}
+	if legacy != nil {
+		keys = append(keys, legacy)
+	}
+
+	return withClock(NewKeyring(keys...), config.Clock), nil
+}
+
+// legacyKey loads the single key of config for verification only. Tokens it
+// signed carry KeyID as their kid, or none when KeyID was empty. A keyring
+// entry with the same ID takes its place.
+func legacyKey(config Config, seen map[string]bool) (*Key, error) {
+	if !config.hasSingleKey() || seen[config.KeyID] {
+		return nil, nil
+	}
+
+	key, err := NewKeyFromConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("legacy key: %w", err)
+	}
+	key.SigningKey = nil
+	return key, nil
+}
+
+// KeyringProvider resolves the keyring for config, deferring load errors until
+// the keyring is used so that constructors keep their signatures.
+func KeyringProvider(config Config) Keyring {
+	keyring, err := NewKeyringFromConfig(config)
+	if err != nil {
+		return &failedKeyring{err: err}
+	}
+	return keyring
+}
+
+func (k *staticKeyring) SigningKey() (*Key, error) {
+	now := k.clock.Now()
+
+	var active *Key
+	for _, key := range k.keys {
+		if !key.CanSign() || !key.IsActiveAt(now) {
+			continue
+		}
+		if active == nil || key.ActivatesAt.After(active.ActivatesAt) {
+			active = key
+		}
+	}
+
+	if active == nil {
+		if len(k.keys) == 1 && !k.keys[0].CanSign() {
+			return nil, ErrMissingSigningKey
+		}
+		return nil, ErrNoActiveKey
+	}
+	return active, nil
+}
+
+func (k *staticKeyring) VerificationKey(kid string) (*Key, error) {
+	now := k.clock.Now()
+
+	// Tokens without a kid, e.g. from before key rotation, can only mean the
+	// one key there is
+	if kid == "" && len(k.keys) == 1 {
+		kid = k.keys[0].ID
+	}
+
+	for _, key := range k.keys {
+		if key.ID != kid {
+			continue
+		}
+		if key.IsRetiredAt(now) {
+			return nil, fmt.Errorf("%w: %q is retired", ErrUnknownKeyID, kid)
+		}
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnknownKeyID, kid)
+}
+
+func (k *staticKeyring) VerificationKeys() ([]*Key, error) {
+	now := k.clock.Now()
+
+	keys := make([]*Key, 0, len(k.keys))
+	for _, key := range k.keys {
+		if !key.IsRetiredAt(now) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+type failedKeyring struct {
+	err error
+}
+
+func (k *failedKeyring) SigningKey() (*Key, error) {
+	return nil, k.err
+}
+
+func (k *failedKeyring) VerificationKey(string) (*Key, error) {
+	return nil, k.err
+}
+
+func (k *failedKeyring) VerificationKeys() ([]*Key, error) {
+	return nil, k.err
+}
@@ -0,0 +1,111 @@
+package jwt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyring_Rotation(t *testing.T) {
+	now := time.Now()
+	privatePEM, _ := generatePEMKeyPair(t, "ES256")
+
+	oldKey := KeyConfig{ID: "2024-01", Secret: "old-secret", ActivatesAt: now.Add(-48 * time.Hour)}
+	newKey := KeyConfig{ID: "2024-02", SigningMethod: "ES256", PrivateKey: privatePEM, ActivatesAt: now.Add(-time.Hour)}
+	nextKey := KeyConfig{ID: "2024-03", Secret: "next-secret", ActivatesAt: now.Add(24 * time.Hour)}
+
+	// Tokens issued before the rotation carry the old kid
+	before := NewJWTService(Config{AccessTokenTTL: time.Hour, Keys: []KeyConfig{oldKey}})
+	oldToken, err := before.GenerateAccessToken(Claims{UserID: "test-user-123"})
+	require.NoError(t, err)
+
+	service := NewJWTService(Config{AccessTokenTTL: time.Hour, Keys: []KeyConfig{oldKey, newKey, nextKey}})
+
+	token, err := service.GenerateAccessToken(Claims{UserID: "test-user-123"})
+	require.NoError(t, err)
+
+	validated, err := service.ValidateToken(token.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-02", validated.Token.Header["kid"])
+	assert.Equal(t, "ES256", validated.Token.Method.Alg())
+
+	// The old key still verifies until it is retired
+	_, err = service.ValidateToken(oldToken.Token)
+	require.NoError(t, err)
+
+	oldKey.RetiresAt = now.Add(-time.Minute)
+	retired := NewJWTService(Config{AccessTokenTTL: time.Hour, Keys: []KeyConfig{oldKey, newKey}})
+	_, err = retired.ValidateToken(oldToken.Token)
+	assert.ErrorIs(t, err, ErrUnknownKeyID)
+}
+
+func TestKeyring_NoActiveKey(t *testing.T) {
+	keyring, err := NewKeyringFromConfig(Config{Keys: []KeyConfig{
+		{ID: "future", Secret: "secret", ActivatesAt: time.Now().Add(time.Hour)},
+	}})
+	require.NoError(t, err)
+
+	_, err = keyring.SigningKey()
+	assert.ErrorIs(t, err, ErrNoActiveKey)
+
+	keys, err := keyring.VerificationKeys()
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+}
+
+func TestKeyring_DuplicateID(t *testing.T) {
+	_, err := NewKeyringFromConfig(Config{Keys: []KeyConfig{
+		{ID: "a", Secret: "one"},
+		{ID: "a", Secret: "two"},
+	}})
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestKeyring_EmptyKidFallsBackToSingleKey(t *testing.T) {
+	keyring, err := NewKeyringFromConfig(Config{Keys: []KeyConfig{{ID: "2024-01", Secret: "secret"}}})
+	require.NoError(t, err)
+
+	key, err := keyring.VerificationKey("")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-01", key.ID)
+
+	// With several keys a missing kid stays ambiguous
+	keyring, err = NewKeyringFromConfig(Config{Keys: []KeyConfig{
+		{ID: "2024-01", Secret: "one"},
+		{ID: "2024-02", Secret: "two"},
+	}})
+	require.NoError(t, err)
+
+	_, err = keyring.VerificationKey("")
+	assert.ErrorIs(t, err, ErrUnknownKeyID)
+}
+
+func TestKeyring_LegacyKeyVerifiesDuringMigration(t *testing.T) {
+	// Tokens from before the keyring carry no kid
+	legacy := NewJWTService(Config{Secret: "legacy-secret", AccessTokenTTL: time.Hour})
+	oldToken, err := legacy.GenerateAccessToken(Claims{UserID: "test-user-123"})
+	require.NoError(t, err)
+
+	service := NewJWTService(Config{
+		Secret:         "legacy-secret",
+		AccessTokenTTL: time.Hour,
+		Keys:           []KeyConfig{{ID: "2024-01", Secret: "new-secret"}},
+	})
+
+	_, err = service.ValidateToken(oldToken.Token)
+	require.NoError(t, err)
+
+	// The legacy key no longer signs
+	token, err := service.GenerateAccessToken(Claims{UserID: "test-user-123"})
+	require.NoError(t, err)
+	validated, err := service.ValidateToken(token.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-01", validated.Token.Header["kid"])
+
+	// Once the single key is removed its tokens are rejected
+	migrated := NewJWTService(Config{AccessTokenTTL: time.Hour, Keys: []KeyConfig{{ID: "2024-01", Secret: "new-secret"}}})
+	_, err = migrated.ValidateToken(oldToken.Token)
+	assert.Error(t, err)
+}
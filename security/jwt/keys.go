@@ -0,0 +1,172 @@
+package jwt
+
+import (
+	"crypto"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key holds the material used to sign and verify tokens with a single algorithm.
+// SigningKey is nil for verify-only keys.
+type Key struct {
+	ID              string
+	Method          jwt.SigningMethod
+	SigningKey      interface{}
+	VerificationKey interface{}
+	ActivatesAt     time.Time
+	RetiresAt       time.Time
+}
+
+func (k *Key) CanSign() bool {
+	return k.SigningKey != nil
+}
+
+// IsActiveAt reports whether the key may sign new tokens at t
+func (k *Key) IsActiveAt(t time.Time) bool {
+	return !t.Before(k.ActivatesAt) && !k.IsRetiredAt(t)
+}
+
+// IsRetiredAt reports whether the key no longer verifies tokens at t
+func (k *Key) IsRetiredAt(t time.Time) bool {
+	return !k.RetiresAt.IsZero() && !t.Before(k.RetiresAt)
+}
+
+// NewKeyFromConfig loads the signing key described by config
+func NewKeyFromConfig(config Config) (*Key, error) {
+	key, err := loadKey(config.SigningMethod, config.Secret, config.PrivateKey, config.PrivateKeyFile, config.PublicKey, config.PublicKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	key.ID = config.KeyID
+	return key, nil
+}
+
+// NewKeyFromKeyConfig loads a single keyring entry
+func NewKeyFromKeyConfig(config KeyConfig) (*Key, error) {
+	key, err := loadKey(config.SigningMethod, config.Secret, config.PrivateKey, config.PrivateKeyFile, config.PublicKey, config.PublicKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", config.ID, err)
+	}
+	key.ID = config.ID
+	key.ActivatesAt = config.ActivatesAt
+	key.RetiresAt = config.RetiresAt
+	return key, nil
+}
+
+func loadKey(methodName, secret, privateKey, privateKeyFile, publicKey, publicKeyFile string) (*Key, error) {
+	if methodName == "" {
+		methodName = jwt.SigningMethodHS256.Alg()
+	}
+
+	method := jwt.GetSigningMethod(methodName)
+	if method == nil || method == jwt.SigningMethodNone {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedSigningMethod, methodName)
+	}
+
+	key := &Key{Method: method}
+
+	if _, ok := method.(*jwt.SigningMethodHMAC); ok {
+		key.SigningKey = []byte(secret)
+		key.VerificationKey = []byte(secret)
+		return key, nil
+	}
+
+	privatePEM, err := readPEM(privateKey, privateKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	publicPEM, err := readPEM(publicKey, publicKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if privatePEM != nil {
+		signer, err := parsePrivateKey(method, privatePEM)
+		if err != nil {
+			return nil, err
+		}
+		key.SigningKey = signer
+		key.VerificationKey = signer.Public()
+	}
+
+	if publicPEM != nil {
+		verifier, err := parsePublicKey(method, publicPEM)
+		if err != nil {
+			return nil, err
+		}
+		key.VerificationKey = verifier
+	}
+
+	if key.VerificationKey == nil {
+		return nil, ErrMissingVerificationKey
+	}
+
+	return key, nil
+}
+
+func readPEM(inline, file string) ([]byte, error) {
+	if inline != "" {
+		return []byte(inline), nil
+	}
+	if file == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read key file: %w", err)
+	}
+	return data, nil
+}
+
+func parsePrivateKey(method jwt.SigningMethod, data []byte) (crypto.Signer, error) {
+	var (
+		key interface{}
+		err error
+	)
+
+	switch method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		key, err = jwt.ParseRSAPrivateKeyFromPEM(data)
+	case *jwt.SigningMethodECDSA:
+		key, err = jwt.ParseECPrivateKeyFromPEM(data)
+	case *jwt.SigningMethodEd25519:
+		key, err = jwt.ParseEdPrivateKeyFromPEM(data)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedSigningMethod, method.Alg())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	return signer, nil
+}
+
+func parsePublicKey(method jwt.SigningMethod, data []byte) (crypto.PublicKey, error) {
+	var (
+		key crypto.PublicKey
+		err error
+	)
+
+	switch method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		key, err = jwt.ParseRSAPublicKeyFromPEM(data)
+	case *jwt.SigningMethodECDSA:
+		key, err = jwt.ParseECPublicKeyFromPEM(data)
+	case *jwt.SigningMethodEd25519:
+		key, err = jwt.ParseEdPublicKeyFromPEM(data)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedSigningMethod, method.Alg())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	return key, nil
+}
@@ -0,0 +1,107 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func generatePEMKeyPair(t *testing.T, method string) (string, string) {
+	t.Helper()
+
+	var (
+		private crypto.Signer
+		err     error
+	)
+	switch method {
+	case "RS256", "PS256":
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ES256":
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "EdDSA":
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		t.Fatalf("unsupported method %s", method)
+	}
+	require.NoError(t, err)
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	require.NoError(t, err)
+
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+	return string(privatePEM), string(publicPEM)
+}
+
+func TestJWTService_AsymmetricSigning(t *testing.T) {
+	for _, method := range []string{"RS256", "PS256", "ES256", "EdDSA"} {
+		t.Run(method, func(t *testing.T) {
+			privatePEM, publicPEM := generatePEMKeyPair(t, method)
+
+			issuer := NewJWTService(Config{
+				SigningMethod:  method,
+				PrivateKey:     privatePEM,
+				AccessTokenTTL: time.Hour,
+			})
+			verifier := NewJWTService(Config{
+				SigningMethod: method,
+				PublicKey:     publicPEM,
+			})
+
+			token, err := issuer.GenerateAccessToken(Claims{UserID: "test-user-123"})
+			require.NoError(t, err)
+
+			validated, err := verifier.ValidateToken(token.Token)
+			require.NoError(t, err)
+			assert.Equal(t, method, validated.Token.Method.Alg())
+			assert.Equal(t, "test-user-123", validated.Claims.UserID)
+
+			// A verifier-only service cannot mint tokens
+			_, err = verifier.GenerateAccessToken(Claims{UserID: "test-user-123"})
+			assert.ErrorIs(t, err, ErrMissingSigningKey)
+		})
+	}
+}
+
+func TestJWTService_RejectsAlgorithmMismatch(t *testing.T) {
+	_, publicPEM := generatePEMKeyPair(t, "RS256")
+
+	hmacService := NewJWTService(Config{
+		Secret:         publicPEM,
+		AccessTokenTTL: time.Hour,
+	})
+	verifier := NewJWTService(Config{
+		SigningMethod: "RS256",
+		PublicKey:     publicPEM,
+	})
+
+	// HS256 token signed with the public key must not validate against RS256
+	token, err := hmacService.GenerateAccessToken(Claims{UserID: "test-user-123"})
+	require.NoError(t, err)
+
+	_, err = verifier.ValidateToken(token.Token)
+	assert.Error(t, err)
+}
+
+func TestNewKeyFromConfig_Errors(t *testing.T) {
+	_, err := NewKeyFromConfig(Config{SigningMethod: "none"})
+	assert.ErrorIs(t, err, ErrUnsupportedSigningMethod)
+
+	_, err = NewKeyFromConfig(Config{SigningMethod: "RS256"})
+	assert.ErrorIs(t, err, ErrMissingVerificationKey)
+
+	_, err = NewKeyFromConfig(Config{SigningMethod: "ES256", PrivateKey: "not a pem"})
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
@@ -111,6 +111,7 @@ func WithRequiredScopes(scopes ...string) ValidationOption {
 	}
 }
 
+// WithRequiredIssuer requires the iss claim, overriding Config.Issuer
 func WithRequiredIssuer(issuer string) ValidationOption {
 	return func(vc *ValidationConfig) error {
 		vc.RequiredIssuer = issuer
@@ -118,6 +119,8 @@ func WithRequiredIssuer(issuer string) ValidationOption {
 	}
 }
 
+// WithRequiredAudience requires audience among the token's aud values,
+// overriding Config.Audience
 func WithRequiredAudience(audience string) ValidationOption {
 	return func(vc *ValidationConfig) error {
 		vc.RequiredAudience = audience
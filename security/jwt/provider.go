@@ -2,16 +2,26 @@ package jwt
 
 // ServiceProvider NewJWTService creates a new JWT service instance
 func ServiceProvider(config Config) Service {
+	config.Keyring = KeyringProvider(config)
+	if config.RefreshTokenRotation && config.RefreshTokenStore == nil {
+		config.RefreshTokenStore = NewMemoryRefreshTokenStore()
+	}
+	if config.RevocationStore == nil {
+		config.RevocationStore = NewMemoryRevocationStore()
+	}
+	config.RevocationStore = withRevocationClock(config.RevocationStore, config.Clock)
+
 	parser := ParserServiceProvider(config)
 	generator := TokenGeneratorProvider(config, parser)
 	validator := TokenValidatorProvider(config, parser)
-	refresher := TokenRefresherProvider(validator, generator)
+	refresher := TokenRefresherProvider(config, validator, generator)
 
 	return &jwtService{
 		config:       config,
 		generator:    generator,
 		validator:    validator,
 		refresher:    refresher,
+		revoker:      NewTokenRevoker(config),
 		claimsParser: parser,
 	}
 }
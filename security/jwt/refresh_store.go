@@ -0,0 +1,85 @@
+package jwt
+
+import (
+	"sync"
+	"time"
+)
+
+type memoryRefreshTokenStore struct {
+	mu        sync.Mutex
+	records   map[string]*RefreshTokenRecord
+	lastSweep time.Time
+}
+
+// NewMemoryRefreshTokenStore creates a process-local refresh token store
+func NewMemoryRefreshTokenStore() RefreshTokenStore {
+	return &memoryRefreshTokenStore{
+		records: make(map[string]*RefreshTokenRecord),
+	}
+}
+
+func (s *memoryRefreshTokenStore) Save(record *RefreshTokenRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(time.Now())
+
+	stored := *record
+	s.records[record.ID] = &stored
+	return nil
+}
+
+func (s *memoryRefreshTokenStore) Find(id string) (*RefreshTokenRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[id]
+	if !ok {
+		return nil, ErrRefreshTokenNotFound
+	}
+
+	found := *record
+	return &found, nil
+}
+
+func (s *memoryRefreshTokenStore) MarkUsed(id string, usedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[id]
+	if !ok {
+		return ErrRefreshTokenNotFound
+	}
+	if record.IsUsed() {
+		return ErrRefreshTokenReused
+	}
+
+	record.UsedAt = &usedAt
+	return nil
+}
+
+func (s *memoryRefreshTokenStore) RevokeFamily(familyID string, revokedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, record := range s.records {
+		if record.FamilyID == familyID && record.RevokedAt == nil {
+			record.RevokedAt = &revokedAt
+		}
+	}
+	return nil
+}
+
+// sweep drops expired records at most once a minute
+func (s *memoryRefreshTokenStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for id, record := range s.records {
+		if now.After(record.ExpiresAt) {
+			delete(s.records, id)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package jwt
+
+import (
+	"errors"
+	"time"
+
+	"github.com/upnext-fng/fulcrum/database"
+	"gorm.io/gorm"
+)
+
+type gormRefreshTokenStore struct {
+	db database.DatabaseService
+}
+
+// NewGormRefreshTokenStore stores refresh tokens in the refresh_tokens table.
+// Migrate RefreshTokenRecord before use.
+func NewGormRefreshTokenStore(db database.DatabaseService) RefreshTokenStore {
+	return &gormRefreshTokenStore{
+		db: db,
+	}
+}
+
+func (s *gormRefreshTokenStore) Save(record *RefreshTokenRecord) error {
+	return s.db.Connection().Create(record).Error
+}
+
+func (s *gormRefreshTokenStore) Find(id string) (*RefreshTokenRecord, error) {
+	var record RefreshTokenRecord
+	if err := s.db.Connection().Where("id = ?", id).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRefreshTokenNotFound
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (s *gormRefreshTokenStore) MarkUsed(id string, usedAt time.Time) error {
+	// The conditional update makes concurrent refreshes with the same token
+	// race safely: only one of them can flip used_at
+	result := s.db.Connection().Model(&RefreshTokenRecord{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", usedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		if _, err := s.Find(id); err != nil {
+			return err
+		}
+		return ErrRefreshTokenReused
+	}
+	return nil
+}
+
+func (s *gormRefreshTokenStore) RevokeFamily(familyID string, revokedAt time.Time) error {
+	return s.db.Connection().Model(&RefreshTokenRecord{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", revokedAt).Error
+}
@@ -1,12 +1,15 @@
 package jwt
 
+import "errors"
+
 type tokenRefresher struct {
+	config    Config
 	validator TokenValidator
 	generator TokenGenerator
 }
 
-func TokenRefresherProvider(validator TokenValidator, generator TokenGenerator) TokenRefresher {
-	return NewTokenRefresher(validator, generator)
+func TokenRefresherProvider(config Config, validator TokenValidator, generator TokenGenerator) TokenRefresher {
+	return NewRotatingTokenRefresher(config, validator, generator)
 }
 
 func NewTokenRefresher(validator TokenValidator, generator TokenGenerator) TokenRefresher {
@@ -16,8 +19,65 @@ func NewTokenRefresher(validator TokenValidator, generator TokenGenerator) Token
 	}
 }
 
+// NewRotatingTokenRefresher creates a refresher that rotates refresh tokens
+// when config enables RefreshTokenRotation. The generator must share config's
+// RefreshTokenStore.
+func NewRotatingTokenRefresher(config Config, validator TokenValidator, generator TokenGenerator) TokenRefresher {
+	return &tokenRefresher{
+		config:    config,
+		validator: validator,
+		generator: generator,
+	}
+}
+
 func (r *tokenRefresher) RefreshAccessToken(refreshToken string, opts ...RefreshOption) (*SignedToken, error) {
-	// Validate refresh token
+	// A plain refresh would leave a rotated token usable forever
+	if r.config.rotatesRefreshTokens() {
+		return nil, ErrRefreshRotationActive
+	}
+
+	validated, err := r.validateRefreshToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert RefreshOption to TokenOption for access token generation
+	tokenOpts := make([]TokenOption, len(opts))
+	for i, opt := range opts {
+		tokenOpts[i] = func(tc *TokenConfig) error {
+			return opt(tc)
+		}
+	}
+
+	return r.generator.GenerateAccessToken(refreshedClaims(validated.Claims), tokenOpts...)
+}
+
+// RefreshTokenPair exchanges a refresh token for new tokens. With rotation
+// enabled the presented token is consumed and a new refresh token from the
+// same family is returned; presenting a consumed token revokes the family.
+// Without rotation the pair is generated as GenerateTokenPair would, which by
+// default only includes an access token.
+func (r *tokenRefresher) RefreshTokenPair(refreshToken string, opts ...TokenPairOption) (*TokenPair, error) {
+	validated, err := r.validateRefreshToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := refreshedClaims(validated.Claims)
+
+	if !r.config.rotatesRefreshTokens() {
+		return r.generator.GenerateTokenPair(claims, opts...)
+	}
+
+	if err := r.consume(validated.Claims); err != nil {
+		return nil, err
+	}
+
+	claims.FamilyID = validated.Claims.FamilyID
+	return r.generator.GenerateTokenPair(claims, append(opts, WithRefreshToken())...)
+}
+
+func (r *tokenRefresher) validateRefreshToken(refreshToken string) (*ValidatedToken, error) {
 	validated, err := r.validator.ValidateToken(refreshToken)
 	if err != nil {
 		return nil, err
@@ -32,28 +92,57 @@ func (r *tokenRefresher) RefreshAccessToken(refreshToken string, opts ...Refresh
 		return nil, ErrInvalidToken
 	}
 
-	// Create new access token with same claims
-	claims := Claims{
-		UserID:    validated.Claims.UserID,
-		TokenType: string(AccessTokenType),
-		ClientID:  validated.Claims.ClientID,
-		DeviceID:  validated.Claims.DeviceID,
-		SessionID: validated.Claims.SessionID,
-		Scopes:    validated.Claims.Scopes,
-		Metadata:  validated.Claims.Metadata,
-		Issuer:    validated.Claims.Issuer,
-		Audience:  validated.Claims.Audience,
-		Subject:   validated.Claims.Subject,
-		Custom:    validated.Claims.Custom,
+	return validated, nil
+}
+
+func (r *tokenRefresher) consume(claims *Claims) error {
+	store := r.config.RefreshTokenStore
+
+	if claims.ID == "" {
+		return ErrInvalidToken
 	}
 
-	// Convert RefreshOption to TokenOption for access token generation
-	tokenOpts := make([]TokenOption, len(opts))
-	for i, opt := range opts {
-		tokenOpts[i] = func(tc *TokenConfig) error {
-			return opt(tc)
+	record, err := store.Find(claims.ID)
+	if err != nil {
+		if errors.Is(err, ErrRefreshTokenNotFound) {
+			return ErrInvalidToken
 		}
+		return err
 	}
 
-	return r.generator.GenerateAccessToken(claims, tokenOpts...)
+	if record.IsRevoked() {
+		return ErrTokenRevoked
+	}
+
+	now := r.config.now()
+	if err := store.MarkUsed(record.ID, now); err != nil {
+		if errors.Is(err, ErrRefreshTokenReused) {
+			// Someone holds a copy of a rotated token; end the whole family
+			if revokeErr := store.RevokeFamily(record.FamilyID, now); revokeErr != nil {
+				return revokeErr
+			}
+		}
+		return err
+	}
+
+	return nil
+}
+
+// refreshedClaims carries the identity of a refresh token over to new tokens
+func refreshedClaims(claims *Claims) Claims {
+	return Claims{
+		UserID:    claims.UserID,
+		TokenType: string(AccessTokenType),
+		ClientID:  claims.ClientID,
+		DeviceID:  claims.DeviceID,
+		SessionID: claims.SessionID,
+		Scopes:    claims.Scopes,
+		Metadata:  claims.Metadata,
+		Issuer:    claims.Issuer,
+		Audience:  claims.Audience,
+		Audiences: claims.Audiences,
+		Subject:   claims.Subject,
+		Custom:    claims.Custom,
+		Extension: claims.Extension,
+	}
 }
@@ -0,0 +1,88 @@
+package jwt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenRefresher_Rotation(t *testing.T) {
+	service := NewJWTService(Config{
+		Secret:               "test-secret-key-123",
+		AccessTokenTTL:       time.Hour,
+		RefreshTokenTTL:      time.Hour * 24,
+		RefreshTokenRotation: true,
+	})
+
+	pair, err := service.GenerateTokenPair(Claims{UserID: "test-user-123"}, WithRefreshToken())
+	require.NoError(t, err)
+
+	rotated, err := service.RefreshTokenPair(pair.RefreshToken.Token)
+	require.NoError(t, err)
+	require.True(t, rotated.HasRefreshToken())
+	assert.NotEqual(t, pair.RefreshToken.Token, rotated.RefreshToken.Token)
+
+	original, err := service.ValidateToken(pair.RefreshToken.Token)
+	require.NoError(t, err)
+	next, err := service.ValidateToken(rotated.RefreshToken.Token)
+	require.NoError(t, err)
+	assert.NotEmpty(t, next.Claims.ID)
+	assert.NotEqual(t, original.Claims.ID, next.Claims.ID)
+	assert.Equal(t, original.Claims.FamilyID, next.Claims.FamilyID)
+
+	// Replaying the consumed token revokes the whole family
+	_, err = service.RefreshTokenPair(pair.RefreshToken.Token)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	_, err = service.RefreshTokenPair(rotated.RefreshToken.Token)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+
+	// Plain access token refresh would bypass rotation
+	_, err = service.RefreshAccessToken(rotated.RefreshToken.Token)
+	assert.ErrorIs(t, err, ErrRefreshRotationActive)
+}
+
+func TestTokenRefresher_RotationSeparateFamilies(t *testing.T) {
+	store := NewMemoryRefreshTokenStore()
+	service := NewJWTService(Config{
+		Secret:               "test-secret-key-123",
+		AccessTokenTTL:       time.Hour,
+		RefreshTokenTTL:      time.Hour * 24,
+		RefreshTokenRotation: true,
+		RefreshTokenStore:    store,
+	})
+
+	laptop, err := service.GenerateRefreshToken(Claims{UserID: "test-user-123"})
+	require.NoError(t, err)
+	phone, err := service.GenerateRefreshToken(Claims{UserID: "test-user-123"})
+	require.NoError(t, err)
+
+	_, err = service.RefreshTokenPair(laptop.Token)
+	require.NoError(t, err)
+	_, err = service.RefreshTokenPair(laptop.Token)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	// Another login is unaffected by the compromised family
+	_, err = service.RefreshTokenPair(phone.Token)
+	assert.NoError(t, err)
+}
+
+func TestTokenRefresher_WithoutRotation(t *testing.T) {
+	service := NewJWTService(Config{
+		Secret:          "test-secret-key-123",
+		AccessTokenTTL:  time.Hour,
+		RefreshTokenTTL: time.Hour * 24,
+	})
+
+	refreshToken, err := service.GenerateRefreshToken(Claims{UserID: "test-user-123"})
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		pair, err := service.RefreshTokenPair(refreshToken.Token)
+		require.NoError(t, err)
+		assert.True(t, pair.AccessToken.IsAccessToken())
+		assert.False(t, pair.HasRefreshToken())
+	}
+}
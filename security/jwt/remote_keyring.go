@@ -0,0 +1,173 @@
+package jwt
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultJWKSCacheTTL           = time.Hour
+	defaultJWKSMinRefreshInterval = 30 * time.Second
+)
+
+type remoteKeyring struct {
+	url                string
+	client             *http.Client
+	cacheTTL           time.Duration
+	minRefreshInterval time.Duration
+
+	group singleflight.Group
+
+	mu          sync.Mutex
+	keys        map[string]*Key
+	fetchedAt   time.Time
+	attemptedAt time.Time
+}
+
+// NewRemoteKeyring creates a verify-only keyring backed by the JWKS document at
+// config.JWKSURL. Keys are cached for JWKSCacheTTL and refetched early when a
+// token names an unknown kid, at most once per JWKSMinRefreshInterval.
+func NewRemoteKeyring(config Config) Keyring {
+	cacheTTL := config.JWKSCacheTTL
+	if cacheTTL <= 0 {
+		cacheTTL = defaultJWKSCacheTTL
+	}
+	minRefreshInterval := config.JWKSMinRefreshInterval
+	if minRefreshInterval <= 0 {
+		minRefreshInterval = defaultJWKSMinRefreshInterval
+	}
+
+	return &remoteKeyring{
+		url:                config.JWKSURL,
+		client:             &http.Client{Timeout: 10 * time.Second},
+		cacheTTL:           cacheTTL,
+		minRefreshInterval: minRefreshInterval,
+	}
+}
+
+// NewRemoteTokenValidator creates a validator that verifies tokens issued by
+// another service using its published JWKS document
+func NewRemoteTokenValidator(config Config, parser ClaimsParser) TokenValidator {
+	config.Keyring = NewRemoteKeyring(config)
+	return NewTokenValidator(config, parser)
+}
+
+func (k *remoteKeyring) SigningKey() (*Key, error) {
+	return nil, ErrMissingSigningKey
+}
+
+func (k *remoteKeyring) VerificationKey(kid string) (*Key, error) {
+	k.mu.Lock()
+	key, found := k.keys[kid]
+	fresh := time.Since(k.fetchedAt) < k.cacheTTL
+	k.mu.Unlock()
+
+	if found && fresh {
+		return key, nil
+	}
+
+	// Refetch when the cache expired or the kid is unknown, but do not let
+	// tokens with made-up kids or an unreachable issuer cause a fetch per request
+	if err := k.refresh(); err != nil {
+		return nil, err
+	}
+
+	k.mu.Lock()
+	key, found = k.keys[kid]
+	k.mu.Unlock()
+
+	if !found {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKeyID, kid)
+	}
+	return key, nil
+}
+
+func (k *remoteKeyring) VerificationKeys() ([]*Key, error) {
+	k.mu.Lock()
+	fresh := time.Since(k.fetchedAt) < k.cacheTTL
+	k.mu.Unlock()
+
+	if !fresh {
+		if err := k.refresh(); err != nil {
+			return nil, err
+		}
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	keys := make([]*Key, 0, len(k.keys))
+	for _, key := range k.keys {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// refresh replaces the cached keys at most once per minRefreshInterval. The
+// fetch runs without holding k.mu and concurrent callers share it. A failed
+// fetch is only reported while no keys have been loaded yet; afterwards the
+// previous keys are kept.
+func (k *remoteKeyring) refresh() error {
+	_, err, _ := k.group.Do(k.url, func() (interface{}, error) {
+		k.mu.Lock()
+		due := time.Since(k.attemptedAt) >= k.minRefreshInterval
+		k.mu.Unlock()
+		if !due {
+			return nil, nil
+		}
+
+		keys, err := k.fetch()
+
+		k.mu.Lock()
+		defer k.mu.Unlock()
+
+		k.attemptedAt = time.Now()
+		if err != nil {
+			if k.keys == nil {
+				return nil, err
+			}
+			return nil, nil
+		}
+		k.keys = keys
+		k.fetchedAt = k.attemptedAt
+		return nil, nil
+	})
+	return err
+}
+
+// fetch downloads and decodes the key set
+func (k *remoteKeyring) fetch() (map[string]*Key, error) {
+	resp, err := k.client.Get(k.url)
+	if err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch jwks: unexpected status %d", resp.StatusCode)
+	}
+
+	var set JSONWebKeySet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("decode jwks: %w", err)
+	}
+
+	keys := make(map[string]*Key, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.Key()
+		if err != nil {
+			// Skip keys we cannot use rather than rejecting the whole set
+			continue
+		}
+		keys[key.ID] = key
+	}
+	return keys, nil
+}
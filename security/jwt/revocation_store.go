@@ -0,0 +1,125 @@
+package jwt
+
+import (
+	"sync"
+	"time"
+)
+
+type revocationEntry struct {
+	before    time.Time
+	expiresAt time.Time
+}
+
+type memoryRevocationStore struct {
+	mu        sync.RWMutex
+	tokens    map[string]revocationEntry
+	sessions  map[string]revocationEntry
+	users     map[string]revocationEntry
+	clock     Clock
+	lastSweep time.Time
+}
+
+// NewMemoryRevocationStore creates a process-local revocation store whose
+// entries are dropped once the tokens they cover have expired
+func NewMemoryRevocationStore() RevocationStore {
+	return &memoryRevocationStore{
+		tokens:   make(map[string]revocationEntry),
+		sessions: make(map[string]revocationEntry),
+		users:    make(map[string]revocationEntry),
+		clock:    SystemClock(),
+	}
+}
+
+// withRevocationClock makes the built-in revocation stores expire entries by clock
+func withRevocationClock(store RevocationStore, clock Clock) RevocationStore {
+	if clock == nil {
+		return store
+	}
+	switch s := store.(type) {
+	case *memoryRevocationStore:
+		s.mu.Lock()
+		s.clock = clock
+		s.mu.Unlock()
+	case *gormRevocationStore:
+		s.clock = clock
+	}
+	return store
+}
+
+func (s *memoryRevocationStore) RevokeToken(jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(s.clock.Now())
+	s.tokens[jti] = revocationEntry{expiresAt: expiresAt}
+	return nil
+}
+
+func (s *memoryRevocationStore) RevokeSession(sessionID string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(s.clock.Now())
+	s.sessions[sessionID] = revocationEntry{expiresAt: expiresAt}
+	return nil
+}
+
+func (s *memoryRevocationStore) RevokeUser(userID string, before time.Time, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(s.clock.Now())
+	before = revocationCutoff(before)
+
+	// Keep the latest cut-off when a user is revoked repeatedly
+	if existing, ok := s.users[userID]; ok && existing.before.After(before) {
+		return nil
+	}
+	s.users[userID] = revocationEntry{before: before, expiresAt: expiresAt}
+	return nil
+}
+
+func (s *memoryRevocationStore) IsRevoked(claims *Claims) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.clock.Now()
+
+	if entry, ok := s.tokens[claims.ID]; ok && claims.ID != "" && now.Before(entry.expiresAt) {
+		return true, nil
+	}
+	if entry, ok := s.sessions[claims.SessionID]; ok && claims.SessionID != "" && now.Before(entry.expiresAt) {
+		return true, nil
+	}
+	if entry, ok := s.users[claims.UserID]; ok && now.Before(entry.expiresAt) {
+		if time.Unix(claims.IssuedAt, 0).Before(entry.before) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// revocationCutoff rounds a user cut-off up to the next whole second. Tokens
+// carry their issue time in whole seconds, so a token issued earlier within
+// the same second as the cut-off cannot be told apart from one issued after
+// it; both are revoked rather than letting the earlier one survive.
+func revocationCutoff(before time.Time) time.Time {
+	return before.Truncate(time.Second).Add(time.Second)
+}
+
+// sweep drops expired entries at most once a minute
+func (s *memoryRevocationStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for _, entries := range []map[string]revocationEntry{s.tokens, s.sessions, s.users} {
+		for key, entry := range entries {
+			if !now.Before(entry.expiresAt) {
+				delete(entries, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package jwt
+
+import (
+	"time"
+
+	"github.com/upnext-fng/fulcrum/database"
+)
+
+const (
+	revocationKindToken   = "jti"
+	revocationKindSession = "session"
+	revocationKindUser    = "user"
+)
+
+type gormRevocationStore struct {
+	db    database.DatabaseService
+	clock Clock
+}
+
+// NewGormRevocationStore stores revocations in the token_revocations table so
+// they are shared by every instance. Migrate RevocationRecord before use.
+func NewGormRevocationStore(db database.DatabaseService) RevocationStore {
+	return &gormRevocationStore{
+		db:    db,
+		clock: SystemClock(),
+	}
+}
+
+func (s *gormRevocationStore) RevokeToken(jti string, expiresAt time.Time) error {
+	return s.insert(RevocationRecord{Kind: revocationKindToken, Value: jti, ExpiresAt: expiresAt})
+}
+
+func (s *gormRevocationStore) RevokeSession(sessionID string, expiresAt time.Time) error {
+	return s.insert(RevocationRecord{Kind: revocationKindSession, Value: sessionID, ExpiresAt: expiresAt})
+}
+
+func (s *gormRevocationStore) RevokeUser(userID string, before time.Time, expiresAt time.Time) error {
+	return s.insert(RevocationRecord{Kind: revocationKindUser, Value: userID, RevokedBefore: revocationCutoff(before), ExpiresAt: expiresAt})
+}
+
+func (s *gormRevocationStore) IsRevoked(claims *Claims) (bool, error) {
+	var count int64
+
+	err := s.db.Connection().Model(&RevocationRecord{}).
+		Where("expires_at > ?", s.clock.Now()).
+		Where(
+			s.db.Connection().
+				Where("kind = ? AND value = ?", revocationKindToken, claims.ID).
+				Or("kind = ? AND value = ?", revocationKindSession, claims.SessionID).
+				Or("kind = ? AND value = ? AND revoked_before > ?", revocationKindUser, claims.UserID, time.Unix(claims.IssuedAt, 0)),
+		).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// insert records a revocation and opportunistically purges expired rows
+func (s *gormRevocationStore) insert(record RevocationRecord) error {
+	db := s.db.Connection()
+
+	if err := db.Create(&record).Error; err != nil {
+		return err
+	}
+	return db.Where("expires_at <= ?", s.clock.Now()).Delete(&RevocationRecord{}).Error
+}
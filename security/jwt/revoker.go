@@ -0,0 +1,87 @@
+package jwt
+
+import (
+	"errors"
+	"time"
+)
+
+// defaultRevocationTTL bounds revocation entries when no token TTLs are configured
+const defaultRevocationTTL = 24 * time.Hour
+
+type tokenRevoker struct {
+	config Config
+}
+
+func TokenRevokerProvider(config Config) TokenRevoker {
+	return NewTokenRevoker(config)
+}
+
+func NewTokenRevoker(config Config) TokenRevoker {
+	return &tokenRevoker{
+		config: config,
+	}
+}
+
+func (r *tokenRevoker) Revoke(jti string) error {
+	if jti == "" {
+		return ErrInvalidToken
+	}
+	return r.store().RevokeToken(jti, r.expiresAt())
+}
+
+func (r *tokenRevoker) RevokeSession(sessionID string) error {
+	if sessionID == "" {
+		return errors.New("session id is required")
+	}
+	return r.store().RevokeSession(sessionID, r.expiresAt())
+}
+
+// RevokeUser revokes every token of userID issued before the given time, or
+// within the same second, as issue times only have second precision
+func (r *tokenRevoker) RevokeUser(userID string, before time.Time) error {
+	if userID == "" {
+		return errors.New("user id is required")
+	}
+	return r.store().RevokeUser(userID, before, before.Add(r.maxTokenTTL()))
+}
+
+func (r *tokenRevoker) store() RevocationStore {
+	if r.config.RevocationStore == nil {
+		return noopRevocationStore{}
+	}
+	return r.config.RevocationStore
+}
+
+// expiresAt is when every token issued until now has expired
+func (r *tokenRevoker) expiresAt() time.Time {
+	return r.config.now().Add(r.maxTokenTTL())
+}
+
+func (r *tokenRevoker) maxTokenTTL() time.Duration {
+	ttl := r.config.AccessTokenTTL
+	if r.config.RefreshTokenTTL > ttl {
+		ttl = r.config.RefreshTokenTTL
+	}
+	if ttl <= 0 {
+		ttl = defaultRevocationTTL
+	}
+	return ttl
+}
+
+type noopRevocationStore struct{}
+
+func (noopRevocationStore) RevokeToken(string, time.Time) error {
+	return errors.New("no revocation store configured")
+}
+
+func (noopRevocationStore) RevokeSession(string, time.Time) error {
+	return errors.New("no revocation store configured")
+}
+
+func (noopRevocationStore) RevokeUser(string, time.Time, time.Time) error {
+	return errors.New("no revocation store configured")
+}
+
+func (noopRevocationStore) IsRevoked(*Claims) (bool, error) {
+	return false, nil
+}
@@ -0,0 +1,117 @@
+package jwt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTService_TokensCarryUniqueJTI(t *testing.T) {
+	service := NewJWTService(Config{Secret: "test-secret-key-123", AccessTokenTTL: time.Hour})
+
+	first, err := service.GenerateAccessToken(Claims{UserID: "test-user-123"})
+	require.NoError(t, err)
+	second, err := service.GenerateAccessToken(Claims{UserID: "test-user-123"})
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, first.ID())
+	assert.NotEqual(t, first.ID(), second.ID())
+
+	validated, err := service.ValidateToken(first.Token)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID(), validated.Claims.ID)
+}
+
+func TestJWTService_Revoke(t *testing.T) {
+	service := NewJWTService(Config{Secret: "test-secret-key-123", AccessTokenTTL: time.Hour})
+
+	revoked, err := service.GenerateAccessToken(Claims{UserID: "test-user-123"})
+	require.NoError(t, err)
+	kept, err := service.GenerateAccessToken(Claims{UserID: "test-user-123"})
+	require.NoError(t, err)
+
+	require.NoError(t, service.Revoke(revoked.ID()))
+
+	_, err = service.ValidateToken(revoked.Token)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+	_, err = service.ValidateToken(kept.Token)
+	assert.NoError(t, err)
+}
+
+func TestJWTService_RevokeSession(t *testing.T) {
+	service := NewJWTService(Config{
+		Secret:          "test-secret-key-123",
+		AccessTokenTTL:  time.Hour,
+		RefreshTokenTTL: time.Hour * 24,
+	})
+
+	pair, err := service.GenerateTokenPair(Claims{UserID: "test-user-123", SessionID: "session-1"}, WithRefreshToken())
+	require.NoError(t, err)
+	other, err := service.GenerateAccessToken(Claims{UserID: "test-user-123", SessionID: "session-2"})
+	require.NoError(t, err)
+
+	require.NoError(t, service.RevokeSession("session-1"))
+
+	_, err = service.ValidateToken(pair.AccessToken.Token)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+	_, err = service.RefreshTokenPair(pair.RefreshToken.Token)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+	_, err = service.ValidateToken(other.Token)
+	assert.NoError(t, err)
+}
+
+func TestJWTService_RevokeUser(t *testing.T) {
+	store := NewMemoryRevocationStore()
+	service := NewJWTService(Config{
+		Secret:          "test-secret-key-123",
+		AccessTokenTTL:  time.Hour,
+		RevocationStore: store,
+	})
+
+	issuedAt := time.Now().Add(-time.Minute)
+	old, err := service.GenerateAccessToken(Claims{UserID: "test-user-123"}, WithIssuedAt(issuedAt))
+	require.NoError(t, err)
+	otherUser, err := service.GenerateAccessToken(Claims{UserID: "other-user"}, WithIssuedAt(issuedAt))
+	require.NoError(t, err)
+
+	require.NoError(t, service.RevokeUser("test-user-123", time.Now().Add(-time.Second)))
+
+	_, err = service.ValidateToken(old.Token)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+	_, err = service.ValidateToken(otherUser.Token)
+	assert.NoError(t, err)
+
+	// Tokens issued after the cut-off are accepted again
+	fresh, err := service.GenerateAccessToken(Claims{UserID: "test-user-123"})
+	require.NoError(t, err)
+	_, err = service.ValidateToken(fresh.Token)
+	assert.NoError(t, err)
+}
+
+func TestJWTService_RevokeUserWithinSecond(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 200_000_000, time.UTC)
+	service := NewJWTService(Config{
+		Secret:         "test-secret-key-123",
+		AccessTokenTTL: time.Hour,
+		Clock:          ClockFunc(func() time.Time { return now }),
+	})
+
+	token, err := service.GenerateAccessToken(Claims{UserID: "test-user-123"})
+	require.NoError(t, err)
+
+	// The token was issued earlier within the same second as the cut-off
+	now = now.Add(300 * time.Millisecond)
+	require.NoError(t, service.RevokeUser("test-user-123", now))
+
+	_, err = service.ValidateToken(token.Token)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+
+	// Tokens issued from the next second on are accepted
+	now = now.Add(time.Second)
+	fresh, err := service.GenerateAccessToken(Claims{UserID: "test-user-123"})
+	require.NoError(t, err)
+	_, err = service.ValidateToken(fresh.Token)
+	assert.NoError(t, err)
+}
@@ -1,25 +1,38 @@
 package jwt
 
+import "time"
+
 type jwtService struct {
 	config       Config
 	generator    TokenGenerator
 	validator    TokenValidator
 	refresher    TokenRefresher
+	revoker      TokenRevoker
 	claimsParser ClaimsParser
 }
 
 // NewJWTService creates a new JWT service instance
 func NewJWTService(config Config) Service {
+	config.Keyring = KeyringProvider(config)
+	if config.RefreshTokenRotation && config.RefreshTokenStore == nil {
+		config.RefreshTokenStore = NewMemoryRefreshTokenStore()
+	}
+	if config.RevocationStore == nil {
+		config.RevocationStore = NewMemoryRevocationStore()
+	}
+	config.RevocationStore = withRevocationClock(config.RevocationStore, config.Clock)
+
 	parser := NewClaimsParser(config)
 	generator := NewTokenGenerator(config, parser)
 	validator := NewTokenValidator(config, parser)
-	refresher := NewTokenRefresher(validator, generator)
+	refresher := NewRotatingTokenRefresher(config, validator, generator)
 
 	return &jwtService{
 		config:       config,
 		generator:    generator,
 		validator:    validator,
 		refresher:    refresher,
+		revoker:      NewTokenRevoker(config),
 		claimsParser: parser,
 	}
 }
@@ -50,3 +63,24 @@ func (s *jwtService) ValidateClaims(claims *Claims, config *ValidationConfig) er
 func (s *jwtService) RefreshAccessToken(refreshToken string, opts ...RefreshOption) (*SignedToken, error) {
 	return s.refresher.RefreshAccessToken(refreshToken, opts...)
 }
+
+func (s *jwtService) RefreshTokenPair(refreshToken string, opts ...TokenPairOption) (*TokenPair, error) {
+	return s.refresher.RefreshTokenPair(refreshToken, opts...)
+}
+
+// Delegation methods for TokenRevoker interface
+func (s *jwtService) Revoke(jti string) error {
+	return s.revoker.Revoke(jti)
+}
+
+func (s *jwtService) RevokeSession(sessionID string) error {
+	return s.revoker.RevokeSession(sessionID)
+}
+
+func (s *jwtService) RevokeUser(userID string, before time.Time) error {
+	return s.revoker.RevokeUser(userID, before)
+}
+
+func (s *jwtService) Keyring() Keyring {
+	return s.config.Keyring
+}
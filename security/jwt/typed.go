@@ -0,0 +1,117 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TypedToken is a validated token together with its decoded typed payload
+type TypedToken[T any] struct {
+	*ValidatedToken
+	Payload T
+}
+
+// GenerateAccessTokenFor issues an access token embedding payload in the ext
+// claim. Go methods cannot take type parameters, hence the generator argument.
+func GenerateAccessTokenFor[T any](generator TokenGenerator, claims Claims, payload T, opts ...TokenOption) (*SignedToken, error) {
+	if err := withExtension(&claims, payload); err != nil {
+		return nil, err
+	}
+	return generator.GenerateAccessToken(claims, opts...)
+}
+
+// GenerateRefreshTokenFor issues a refresh token embedding payload
+func GenerateRefreshTokenFor[T any](generator TokenGenerator, claims Claims, payload T, opts ...RefreshOption) (*SignedToken, error) {
+	if err := withExtension(&claims, payload); err != nil {
+		return nil, err
+	}
+	return generator.GenerateRefreshToken(claims, opts...)
+}
+
+// GenerateTokenPairFor issues a token pair embedding payload in both tokens.
+// Refreshed tokens carry the payload over unchanged.
+func GenerateTokenPairFor[T any](generator TokenGenerator, claims Claims, payload T, opts ...TokenPairOption) (*TokenPair, error) {
+	if err := withExtension(&claims, payload); err != nil {
+		return nil, err
+	}
+	return generator.GenerateTokenPair(claims, opts...)
+}
+
+// ValidateTokenAs validates the token and decodes its typed payload
+func ValidateTokenAs[T any](validator TokenValidator, tokenString string, opts ...ValidationOption) (*TypedToken[T], error) {
+	validated, err := validator.ValidateToken(tokenString, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := DecodeClaimsAs[T](validated.Claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TypedToken[T]{
+		ValidatedToken: validated,
+		Payload:        payload,
+	}, nil
+}
+
+// DecodeClaimsAs decodes the typed payload of already validated claims.
+// Fields whose JSON does not match T are reported as a ValidationError.
+func DecodeClaimsAs[T any](claims *Claims) (T, error) {
+	var payload T
+
+	if claims == nil || len(claims.Extension) == 0 {
+		return payload, ErrMissingTypedClaims
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(claims.Extension))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			field := "ext"
+			if typeErr.Field != "" {
+				field = "ext." + typeErr.Field
+			}
+			return payload, NewValidationError(field, fmt.Sprintf("cannot decode %s into %s", typeErr.Value, typeErr.Type), ErrInvalidClaims)
+		}
+		return payload, NewValidationError("ext", err.Error(), ErrInvalidClaims)
+	}
+
+	return payload, nil
+}
+
+func withExtension[T any](claims *Claims, payload T) error {
+	extension, err := json.Marshal(payload)
+	if err != nil {
+		return NewValidationError("ext", err.Error(), ErrInvalidClaims)
+	}
+	claims.Extension = extension
+	return nil
+}
+
+// rawExtension extracts the ext claim verbatim from a compact JWS
+func rawExtension(tokenString string) (json.RawMessage, error) {
+	parts := strings.Split(tokenString, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := jwt.NewParser().DecodeSegment(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var claims struct {
+		Extension json.RawMessage `json:"ext"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidClaims
+	}
+	return claims.Extension, nil
+}
@@ -0,0 +1,78 @@
+package jwt
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testProfile struct {
+	Plan         string            `json:"plan"`
+	RenewsAt     time.Time         `json:"renews_at"`
+	FeatureFlags map[string]bool   `json:"feature_flags"`
+	Experiments  map[string]string `json:"experiments"`
+	Quota        int64             `json:"quota"`
+}
+
+func TestTypedClaims_RoundTrip(t *testing.T) {
+	service := NewJWTService(Config{
+		Secret:          "test-secret-key-123",
+		AccessTokenTTL:  time.Hour,
+		RefreshTokenTTL: time.Hour * 24,
+	})
+
+	profile := testProfile{
+		Plan:         "pro",
+		RenewsAt:     time.Date(2030, 1, 2, 3, 4, 5, 6789, time.UTC),
+		FeatureFlags: map[string]bool{"beta": true, "legacy": false},
+		Experiments:  map[string]string{"checkout": "variant-b"},
+		Quota:        math.MaxInt64,
+	}
+
+	token, err := GenerateAccessTokenFor(service, Claims{UserID: "test-user-123"}, profile)
+	require.NoError(t, err)
+
+	typed, err := ValidateTokenAs[testProfile](service, token.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "test-user-123", typed.Claims.UserID)
+	assert.Equal(t, profile.Plan, typed.Payload.Plan)
+	assert.True(t, profile.RenewsAt.Equal(typed.Payload.RenewsAt))
+	assert.Equal(t, profile.FeatureFlags, typed.Payload.FeatureFlags)
+	assert.Equal(t, profile.Experiments, typed.Payload.Experiments)
+	assert.Equal(t, profile.Quota, typed.Payload.Quota)
+
+	// The payload survives a refresh
+	refreshToken, err := GenerateRefreshTokenFor(service, Claims{UserID: "test-user-123"}, profile)
+	require.NoError(t, err)
+	refreshed, err := service.RefreshAccessToken(refreshToken.Token)
+	require.NoError(t, err)
+	typed, err = ValidateTokenAs[testProfile](service, refreshed.Token)
+	require.NoError(t, err)
+	assert.Equal(t, profile.Quota, typed.Payload.Quota)
+}
+
+func TestTypedClaims_DecodeErrors(t *testing.T) {
+	service := NewJWTService(Config{Secret: "test-secret-key-123", AccessTokenTTL: time.Hour})
+
+	plain, err := service.GenerateAccessToken(Claims{UserID: "test-user-123"})
+	require.NoError(t, err)
+	_, err = ValidateTokenAs[testProfile](service, plain.Token)
+	assert.ErrorIs(t, err, ErrMissingTypedClaims)
+
+	mismatched, err := GenerateAccessTokenFor(service, Claims{UserID: "test-user-123"}, map[string]interface{}{
+		"plan":  "pro",
+		"quota": "unlimited",
+	})
+	require.NoError(t, err)
+
+	_, err = ValidateTokenAs[testProfile](service, mismatched.Token)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrInvalidClaims)
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "ext.quota", validationErr.Field)
+}
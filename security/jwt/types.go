@@ -1,6 +1,7 @@
 package jwt
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -23,6 +24,7 @@ type SignedToken struct {
 	ExpiresIn int64     `json:"expires_in"`
 
 	// Internal metadata (không expose trong JSON)
+	id        string    `json:"-"`
 	tokenKind TokenType `json:"-"`
 	scope     []string  `json:"-"`
 }
@@ -55,6 +57,8 @@ type TokenPairOption func(*TokenPairConfig) error
 type ValidationOption func(*ValidationConfig) error
 
 type Claims struct {
+	ID        string                 `json:"jti,omitempty"`
+	FamilyID  string                 `json:"fid,omitempty"`
 	UserID    string                 `json:"user_id"`
 	TokenType string                 `json:"token_type"`
 	ClientID  string                 `json:"client_id,omitempty"`
@@ -64,11 +68,15 @@ type Claims struct {
 	Metadata  map[string]interface{} `json:"metadata,omitempty"`
 	Issuer    string                 `json:"iss,omitempty"`
 	Audience  string                 `json:"aud,omitempty"`
+	Audiences []string               `json:"-"`
 	Subject   string                 `json:"sub,omitempty"`
 	ExpiresAt int64                  `json:"exp,omitempty"`
 	IssuedAt  int64                  `json:"iat,omitempty"`
 	NotBefore int64                  `json:"nbf,omitempty"`
 	Custom    map[string]interface{} `json:"custom,omitempty"`
+
+	// Extension holds a typed payload as raw JSON, see GenerateAccessTokenFor
+	Extension json.RawMessage `json:"ext,omitempty"`
 }
 
 // Helper methods for Claims
@@ -144,6 +152,19 @@ func (c *Claims) GetCustomBool(key string) bool {
 	return false
 }
 
+// HasAudience reports whether audience is one of the token's aud values
+func (c *Claims) HasAudience(audience string) bool {
+	if c.Audience == audience {
+		return true
+	}
+	for _, aud := range c.Audiences {
+		if aud == audience {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Claims) SetMetadata(key string, value interface{}) {
 	if c.Metadata == nil {
 		c.Metadata = make(map[string]interface{})
@@ -181,6 +202,45 @@ func (c *Claims) IsValidAt(t time.Time) bool {
 	return true
 }
 
+// RefreshTokenRecord tracks an issued refresh token for rotation and reuse
+// detection. Tokens rotated from one another share a FamilyID.
+type RefreshTokenRecord struct {
+	ID        string     `json:"id" gorm:"primaryKey;size:64"`
+	FamilyID  string     `json:"family_id" gorm:"index;size:64;not null"`
+	UserID    string     `json:"user_id" gorm:"index;size:255;not null"`
+	IssuedAt  time.Time  `json:"issued_at"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"index"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (RefreshTokenRecord) TableName() string {
+	return "refresh_tokens"
+}
+
+func (r *RefreshTokenRecord) IsUsed() bool {
+	return r.UsedAt != nil
+}
+
+func (r *RefreshTokenRecord) IsRevoked() bool {
+	return r.RevokedAt != nil
+}
+
+// RevocationRecord is a revoked token, session or user as persisted by the
+// GORM revocation store
+type RevocationRecord struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	Kind          string    `json:"kind" gorm:"size:16;not null;index:idx_token_revocations_lookup"`
+	Value         string    `json:"value" gorm:"size:255;not null;index:idx_token_revocations_lookup"`
+	RevokedBefore time.Time `json:"revoked_before"`
+	ExpiresAt     time.Time `json:"expires_at" gorm:"index"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (RevocationRecord) TableName() string {
+	return "token_revocations"
+}
+
 type ValidatedToken struct {
 	Token     *jwt.Token `json:"-"`
 	Claims    *Claims    `json:"claims"`
@@ -209,6 +269,11 @@ func (st *SignedToken) ShouldRefresh(threshold time.Duration) bool {
 	return time.Until(st.ExpiresAt) <= threshold
 }
 
+// ID returns the token's jti claim
+func (st *SignedToken) ID() string {
+	return st.id
+}
+
 func (st *SignedToken) IsAccessToken() bool {
 	return st.tokenKind == AccessTokenType
 }
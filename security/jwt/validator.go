@@ -1,6 +1,9 @@
 package jwt
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -9,6 +12,7 @@ import (
 type tokenValidator struct {
 	config       Config
 	claimsParser ClaimsParser
+	keyring      Keyring
 }
 
 func TokenValidatorProvider(config Config, parser ClaimsParser) TokenValidator {
@@ -19,6 +23,7 @@ func NewTokenValidator(config Config, parser ClaimsParser) TokenValidator {
 	return &tokenValidator{
 		config:       config,
 		claimsParser: parser,
+		keyring:      KeyringProvider(config),
 	}
 }
 
@@ -32,22 +37,22 @@ func (v *tokenValidator) ValidateToken(tokenString string, opts ...ValidationOpt
 		}
 	}
 
-	// Parse token
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, ErrInvalidSignature
-		}
-		return []byte(v.config.Secret), nil
-	})
+	// Parse token, accepting only the algorithm of the key named by kid.
+	// Time based claims are checked by ValidateClaims so options apply.
+	token, err := jwt.NewParser(jwt.WithoutClaimsValidation()).Parse(tokenString, v.keyFunc)
 
 	if err != nil {
-		return nil, err
+		return nil, newParseError(err)
 	}
 
 	// Parse claims
 	parsedClaims, err := v.claimsParser.ParseClaims(token)
 	if err != nil {
-		return nil, err
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			return nil, err
+		}
+		return nil, newClaimError(CodeInvalidClaims, "claims", err.Error(), err)
 	}
 
 	// Validate claims if not skipped
@@ -55,6 +60,10 @@ func (v *tokenValidator) ValidateToken(tokenString string, opts ...ValidationOpt
 		if err := v.ValidateClaims(parsedClaims, config); err != nil {
 			return nil, err
 		}
+
+		if err := v.checkRevocation(parsedClaims); err != nil {
+			return nil, err
+		}
 	}
 
 	var expiresAt time.Time
@@ -70,54 +79,99 @@ func (v *tokenValidator) ValidateToken(tokenString string, opts ...ValidationOpt
 	}, nil
 }
 
+func (v *tokenValidator) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	key, err := v.keyring.VerificationKey(kid)
+	if err != nil {
+		return nil, err
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, ErrInvalidSignature
+	}
+	return key.VerificationKey, nil
+}
+
 func (v *tokenValidator) ValidateClaims(claims *Claims, config *ValidationConfig) error {
 	if claims == nil {
-		return ErrInvalidClaims
+		return newClaimError(CodeInvalidClaims, "claims", "claims are missing", ErrInvalidClaims)
 	}
 
+	now := v.config.now()
+	leeway := v.config.Leeway
+
 	// Check expiration
 	if !config.SkipExpiration && claims.ExpiresAt > 0 {
-		if time.Now().Unix() > claims.ExpiresAt {
-			return ErrTokenExpired
+		expiresAt := time.Unix(claims.ExpiresAt, 0)
+		if now.After(expiresAt.Add(leeway)) {
+			return newClaimError(CodeTokenExpired, "exp", fmt.Sprintf("token expired at %s", expiresAt.UTC().Format(time.RFC3339)), ErrTokenExpired)
 		}
 	}
 
 	// Check not before
-	if claims.NotBefore > 0 && time.Now().Unix() < claims.NotBefore {
-		return ErrInvalidToken
+	if claims.NotBefore > 0 {
+		notBefore := time.Unix(claims.NotBefore, 0)
+		if now.Add(leeway).Before(notBefore) {
+			return newClaimError(CodeTokenNotYetValid, "nbf", fmt.Sprintf("token is not valid before %s", notBefore.UTC().Format(time.RFC3339)), ErrTokenNotYetValid)
+		}
 	}
 
-	// Check issuer
-	if config.RequiredIssuer != "" && claims.Issuer != config.RequiredIssuer {
-		return ErrInvalidToken
+	// Check issuer, defaulting to the configured one
+	issuer := config.RequiredIssuer
+	if issuer == "" {
+		issuer = v.config.Issuer
+	}
+	if issuer != "" && claims.Issuer != issuer {
+		return newClaimError(CodeIssuerMismatch, "iss", fmt.Sprintf("issuer %q is not trusted", claims.Issuer), ErrInvalidIssuer)
 	}
 
-	// Check audience
-	if config.RequiredAudience != "" && claims.Audience != config.RequiredAudience {
-		return ErrInvalidToken
+	// Check audience, defaulting to the configured one
+	audience := config.RequiredAudience
+	if audience == "" {
+		audience = v.config.Audience
+	}
+	if audience != "" && !claims.HasAudience(audience) {
+		return newClaimError(CodeAudienceMismatch, "aud", fmt.Sprintf("token is not intended for %q", audience), ErrInvalidAudience)
 	}
 
 	// Check scopes
-	if len(config.RequiredScopes) > 0 {
-		if !v.hasRequiredScopes(claims.Scopes, config.RequiredScopes) {
-			return ErrInvalidToken
-		}
+	if missing := v.missingScopes(claims.Scopes, config.RequiredScopes); len(missing) > 0 {
+		err := newClaimError(CodeScopeMissing, "scopes", fmt.Sprintf("missing scopes: %s", strings.Join(missing, " ")), ErrInsufficientScope)
+		err.Missing = missing
+		return err
+	}
+
+	return nil
+}
+
+func (v *tokenValidator) checkRevocation(claims *Claims) error {
+	if v.config.RevocationStore == nil {
+		return nil
 	}
 
+	revoked, err := v.config.RevocationStore.IsRevoked(claims)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return newClaimError(CodeTokenRevoked, "jti", "token has been revoked", ErrTokenRevoked)
+	}
 	return nil
 }
 
-func (v *tokenValidator) hasRequiredScopes(tokenScopes, requiredScopes []string) bool {
+func (v *tokenValidator) missingScopes(tokenScopes, requiredScopes []string) []string {
 	scopeMap := make(map[string]bool)
 	for _, scope := range tokenScopes {
 		scopeMap[scope] = true
 	}
 
+	var missing []string
 	for _, required := range requiredScopes {
 		if !scopeMap[required] {
-			return false
+			missing = append(missing, required)
 		}
 	}
 
-	return true
+	return missing
 }
@@ -0,0 +1,156 @@
+package jwt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenValidator_Audience(t *testing.T) {
+	service := NewJWTService(Config{
+		Secret:         "test-secret-key-123",
+		AccessTokenTTL: time.Hour,
+		Audience:       "api",
+	})
+
+	token, err := service.GenerateAccessToken(Claims{UserID: "test-user-123"}, WithAudience("web", "api"))
+	require.NoError(t, err)
+
+	validated, err := service.ValidateToken(token.Token)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"web", "api"}, validated.Claims.Audiences)
+	assert.Equal(t, "web", validated.Claims.Audience)
+
+	_, err = service.ValidateToken(token.Token, WithRequiredAudience("web"))
+	assert.NoError(t, err)
+	_, err = service.ValidateToken(token.Token, WithRequiredAudience("admin"))
+	assert.ErrorIs(t, err, ErrInvalidAudience)
+
+	// The configured audience is enforced by default
+	other, err := service.GenerateAccessToken(Claims{UserID: "test-user-123"}, WithAudience("admin"))
+	require.NoError(t, err)
+	_, err = service.ValidateToken(other.Token)
+	assert.ErrorIs(t, err, ErrInvalidAudience)
+}
+
+func TestTokenGenerator_SingleAudiences(t *testing.T) {
+	service := NewJWTService(Config{Secret: "test-secret-key-123", AccessTokenTTL: time.Hour})
+
+	token, err := service.GenerateAccessToken(Claims{UserID: "test-user-123", Audiences: []string{"mobile"}})
+	require.NoError(t, err)
+
+	validated, err := service.ValidateToken(token.Token, WithRequiredAudience("mobile"))
+	require.NoError(t, err)
+	assert.Equal(t, "mobile", validated.Claims.Audience)
+}
+
+func TestTokenValidator_Issuer(t *testing.T) {
+	config := Config{
+		Secret:         "test-secret-key-123",
+		AccessTokenTTL: time.Hour,
+		Issuer:         "auth.example.com",
+	}
+	service := NewJWTService(config)
+
+	token, err := service.GenerateAccessToken(Claims{UserID: "test-user-123"})
+	require.NoError(t, err)
+	_, err = service.ValidateToken(token.Token)
+	assert.NoError(t, err)
+	_, err = service.ValidateToken(token.Token, WithRequiredIssuer("other.example.com"))
+	assert.ErrorIs(t, err, ErrInvalidIssuer)
+
+	// A service trusting another issuer rejects the token by default
+	config.Issuer = "other.example.com"
+	_, err = NewJWTService(config).ValidateToken(token.Token)
+	assert.ErrorIs(t, err, ErrInvalidIssuer)
+}
+
+func TestTokenValidator_SkipExpirationAndScopes(t *testing.T) {
+	service := NewJWTService(Config{Secret: "test-secret-key-123", AccessTokenTTL: time.Hour})
+
+	token, err := service.GenerateAccessToken(
+		Claims{UserID: "test-user-123", Scopes: []string{"read"}},
+		WithIssuedAt(time.Now().Add(-2*time.Hour)),
+		WithExpiresAt(time.Now().Add(-time.Hour)),
+	)
+	require.NoError(t, err)
+
+	_, err = service.ValidateToken(token.Token)
+	assert.ErrorIs(t, err, ErrTokenExpired)
+
+	validated, err := service.ValidateToken(token.Token, SkipExpiration())
+	require.NoError(t, err)
+	assert.Equal(t, "test-user-123", validated.Claims.UserID)
+
+	_, err = service.ValidateToken(token.Token, SkipExpiration(), WithRequiredScopes("write"))
+	assert.ErrorIs(t, err, ErrInsufficientScope)
+}
+
+func TestTokenValidator_ClockAndLeeway(t *testing.T) {
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := ClockFunc(func() time.Time { return now })
+
+	service := NewJWTService(Config{
+		Secret:         "test-secret-key-123",
+		AccessTokenTTL: time.Minute,
+		Leeway:         30 * time.Second,
+		Clock:          clock,
+	})
+
+	token, err := service.GenerateAccessToken(Claims{UserID: "test-user-123"})
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(time.Minute), token.ExpiresAt)
+
+	// A node whose clock runs slightly behind still accepts the token
+	now = now.Add(-20 * time.Second)
+	_, err = service.ValidateToken(token.Token)
+	assert.NoError(t, err)
+
+	now = now.Add(-time.Minute)
+	_, err = service.ValidateToken(token.Token)
+	assert.ErrorIs(t, err, ErrTokenNotYetValid)
+
+	// Expiry is tolerated within the leeway only
+	now = token.ExpiresAt.Add(20 * time.Second)
+	_, err = service.ValidateToken(token.Token)
+	assert.NoError(t, err)
+
+	now = token.ExpiresAt.Add(time.Minute)
+	_, err = service.ValidateToken(token.Token)
+	assert.ErrorIs(t, err, ErrTokenExpired)
+}
+
+func TestTokenValidator_ReasonCodes(t *testing.T) {
+	service := NewJWTService(Config{
+		Secret:         "test-secret-key-123",
+		AccessTokenTTL: time.Hour,
+		Issuer:         "auth.example.com",
+	})
+
+	token, err := service.GenerateAccessToken(Claims{UserID: "test-user-123", Scopes: []string{"read"}})
+	require.NoError(t, err)
+
+	_, err = service.ValidateToken(token.Token, WithRequiredScopes("read", "write", "admin"))
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, CodeScopeMissing, validationErr.Code)
+	assert.Equal(t, []string{"write", "admin"}, validationErr.Missing)
+	assert.ErrorIs(t, err, ErrInsufficientScope)
+
+	_, err = service.ValidateToken(token.Token, WithRequiredAudience("admin"))
+	assert.Equal(t, CodeAudienceMismatch, ErrorCodeOf(err))
+	_, err = service.ValidateToken(token.Token, WithRequiredIssuer("other.example.com"))
+	assert.Equal(t, CodeIssuerMismatch, ErrorCodeOf(err))
+
+	other := NewJWTService(Config{Secret: "other-secret-key-456", Issuer: "auth.example.com"})
+	_, err = other.ValidateToken(token.Token)
+	assert.Equal(t, CodeInvalidSignature, ErrorCodeOf(err))
+	_, err = service.ValidateToken("not-a-token")
+	assert.Equal(t, CodeTokenMalformed, ErrorCodeOf(err))
+
+	require.NoError(t, service.Revoke(token.ID()))
+	_, err = service.ValidateToken(token.Token)
+	assert.Equal(t, CodeTokenRevoked, ErrorCodeOf(err))
+}
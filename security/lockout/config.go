@@ -0,0 +1,24 @@
+package lockout
+
+import "time"
+
+type Config struct {
+	// MaxAttempts failures lock an account, MaxAttemptsPerIP lock a client
+	// address across accounts
+	MaxAttempts      int `mapstructure:"max_attempts"`
+	MaxAttemptsPerIP int `mapstructure:"max_attempts_per_ip"`
+
+	// Window forgets failures older than this while they have not locked
+	// the account or address yet
+	Window time.Duration `mapstructure:"window"`
+
+	// Decay forgets the failures of a record that has been locked once
+	// nothing failed for this long. Until then every failure past the limit
+	// doubles the lockout, however long the attacker waits in between.
+	Decay time.Duration `mapstructure:"decay"`
+
+	// The first lockout lasts BaseDelay and every further failure doubles
+	// it, up to MaxDelay
+	BaseDelay time.Duration `mapstructure:"base_delay"`
+	MaxDelay  time.Duration `mapstructure:"max_delay"`
+}
@@ -0,0 +1,5 @@
+package lockout
+
+import "go.uber.org/fx"
+
+var Module = fx.Provide(NewService)
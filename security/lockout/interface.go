@@ -0,0 +1,40 @@
+package lockout
+
+import "time"
+
+type Service interface {
+	// Guard wraps a password check, such as a call to VerifyPassword. Locked
+	// accounts and addresses are rejected with a *LockedError before verify
+	// runs. A verify error wrapping ErrInvalidCredentials or
+	// password.ErrMismatchedPassword counts against both, other errors are
+	// returned without counting, and a successful verify clears the
+	// account's failures. The attempt is counted before verify runs, so
+	// concurrent attempts cannot get past the limits. An empty ip skips
+	// per-address tracking.
+	Guard(account, ip string, verify func() error) error
+
+	// Check returns a *LockedError while the account or ip is locked
+	Check(account, ip string) error
+	RecordFailure(account, ip string) error
+	RecordSuccess(account, ip string) error
+
+	Status(account string) (*Status, error)
+	// Unlock and UnlockIP clear lockouts and failures, e.g. for admins
+	Unlock(account string) error
+	UnlockIP(ip string) error
+}
+
+// Store counts failures per key. Every method must be atomic.
+type Store interface {
+	// Fail counts a failure at now and returns the updated record. Counting
+	// restarts once policy considers the failures expired, and reaching
+	// policy.Limit locks the key for policy.Delay. A key locked at now is
+	// not counted; its record is returned together with ErrLocked.
+	Fail(key string, now time.Time, policy Policy) (*Record, error)
+	// Release takes back a failure counted by Fail at now, for attempts that
+	// did not fail after all
+	Release(key string, now time.Time, policy Policy) error
+	// Get returns ErrRecordNotFound for keys without failures
+	Get(key string) (*Record, error)
+	Reset(key string) error
+}
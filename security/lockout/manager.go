@@ -0,0 +1,224 @@
+package lockout
+
+import (
+	"errors"
+	"time"
+
+	"github.com/upnext-fng/fulcrum/security/password"
+)
+
+const (
+	defaultMaxAttempts      = 5
+	defaultMaxAttemptsPerIP = 20
+	defaultWindow           = 15 * time.Minute
+	defaultDecay            = 24 * time.Hour
+	defaultBaseDelay        = time.Minute
+	defaultMaxDelay         = time.Hour
+
+	scopeAccount = "account"
+	scopeIP      = "ip"
+)
+
+type manager struct {
+	config Config
+	store  Store
+	now    func() time.Time
+}
+
+func NewManager(config Config, store Store) Service {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaultMaxAttempts
+	}
+	if config.MaxAttemptsPerIP <= 0 {
+		config.MaxAttemptsPerIP = defaultMaxAttemptsPerIP
+	}
+	if config.Window <= 0 {
+		config.Window = defaultWindow
+	}
+	if config.Decay <= 0 {
+		config.Decay = defaultDecay
+	}
+	// Locked records must not be forgotten sooner than unlocked ones
+	config.Decay = max(config.Decay, config.Window)
+	if config.BaseDelay <= 0 {
+		config.BaseDelay = defaultBaseDelay
+	}
+	if config.MaxDelay <= 0 {
+		config.MaxDelay = defaultMaxDelay
+	}
+
+	return &manager{
+		config: config,
+		store:  store,
+		now:    time.Now,
+	}
+}
+
+func (m *manager) Guard(account, ip string, verify func() error) error {
+	now := m.now()
+
+	// Count the attempt as failed up front and take it back once it turns
+	// out otherwise, so concurrent attempts cannot get past the limits
+	keys := m.keys(account, ip)
+	if _, err := m.fail(keys, now); err != nil {
+		return err
+	}
+
+	err := verify()
+	// Only wrong credentials count; an unavailable database must not lock
+	// out legitimate users
+	if isCredentialError(err) {
+		return err
+	}
+
+	if releaseErr := m.release(keys, now); releaseErr != nil && err == nil {
+		return releaseErr
+	}
+	if err != nil {
+		return err
+	}
+	return m.RecordSuccess(account, ip)
+}
+
+func (m *manager) Check(account, ip string) error {
+	now := m.now()
+	for _, key := range m.keys(account, ip) {
+		record, err := m.store.Get(key.key)
+		if errors.Is(err, ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if record.LockedAt(now) {
+			return &LockedError{Scope: key.scope, RetryAfter: record.LockedUntil.Sub(now)}
+		}
+	}
+	return nil
+}
+
+// RecordFailure counts a failed attempt and returns a *LockedError when the
+// account or ip is locked
+func (m *manager) RecordFailure(account, ip string) error {
+	now := m.now()
+
+	keys := m.keys(account, ip)
+	records, err := m.fail(keys, now)
+	if err != nil {
+		return err
+	}
+
+	for i, record := range records {
+		if record.LockedAt(now) {
+			return &LockedError{Scope: keys[i].scope, RetryAfter: record.LockedUntil.Sub(now)}
+		}
+	}
+	return nil
+}
+
+// fail counts a failure against every key. A locked key is not counted and
+// fails with a *LockedError, releasing the failures counted before it.
+func (m *manager) fail(keys []trackedKey, now time.Time) ([]*Record, error) {
+	records := make([]*Record, 0, len(keys))
+	for i, key := range keys {
+		record, err := m.store.Fail(key.key, now, m.policy(key))
+		if err == nil {
+			records = append(records, record)
+			continue
+		}
+
+		if releaseErr := m.release(keys[:i], now); releaseErr != nil {
+			return nil, releaseErr
+		}
+		if errors.Is(err, ErrLocked) {
+			return nil, &LockedError{Scope: key.scope, RetryAfter: record.LockedUntil.Sub(now)}
+		}
+		return nil, err
+	}
+	return records, nil
+}
+
+// release takes back the failures fail counted at now
+func (m *manager) release(keys []trackedKey, now time.Time) error {
+	for _, key := range keys {
+		if err := m.store.Release(key.key, now, m.policy(key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordSuccess clears the account's failures. Failures of the address are
+// kept, so one valid login does not hide stuffing of other accounts.
+func (m *manager) RecordSuccess(account, ip string) error {
+	if account == "" {
+		return nil
+	}
+	return m.store.Reset(accountKey(account))
+}
+
+func (m *manager) Status(account string) (*Status, error) {
+	record, err := m.store.Get(accountKey(account))
+	if errors.Is(err, ErrRecordNotFound) {
+		return &Status{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{Failures: record.Failures}
+	if record.LockedAt(m.now()) {
+		status.Locked = true
+		status.LockedUntil = record.LockedUntil
+	}
+	return status, nil
+}
+
+func (m *manager) Unlock(account string) error {
+	return m.store.Reset(accountKey(account))
+}
+
+func (m *manager) UnlockIP(ip string) error {
+	return m.store.Reset(ipKey(ip))
+}
+
+func (m *manager) policy(key trackedKey) Policy {
+	return Policy{
+		Limit:     key.limit,
+		Window:    m.config.Window,
+		Decay:     m.config.Decay,
+		BaseDelay: m.config.BaseDelay,
+		MaxDelay:  m.config.MaxDelay,
+	}
+}
+
+// isCredentialError reports whether a verify error is a failed login
+func isCredentialError(err error) bool {
+	return errors.Is(err, ErrInvalidCredentials) || errors.Is(err, password.ErrMismatchedPassword)
+}
+
+type trackedKey struct {
+	key   string
+	scope string
+	limit int
+}
+
+func (m *manager) keys(account, ip string) []trackedKey {
+	var keys []trackedKey
+	if account != "" {
+		keys = append(keys, trackedKey{key: accountKey(account), scope: scopeAccount, limit: m.config.MaxAttempts})
+	}
+	if ip != "" {
+		keys = append(keys, trackedKey{key: ipKey(ip), scope: scopeIP, limit: m.config.MaxAttemptsPerIP})
+	}
+	return keys
+}
+
+func accountKey(account string) string {
+	return scopeAccount + ":" + account
+}
+
+func ipKey(ip string) string {
+	return scopeIP + ":" + ip
+}
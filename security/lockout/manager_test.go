@@ -0,0 +1,221 @@
+package lockout
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/upnext-fng/fulcrum/security/password"
+)
+
+var errWrongPassword = fmt.Errorf("%w: wrong password", ErrInvalidCredentials)
+
+func wrongPassword() error { return errWrongPassword }
+func rightPassword() error { return nil }
+
+func TestLockout_LocksAccountAfterMaxAttempts(t *testing.T) {
+	service := NewManager(Config{MaxAttempts: 3, BaseDelay: time.Minute}, NewMemoryStore())
+
+	for i := 0; i < 3; i++ {
+		assert.ErrorIs(t, service.Guard("alice", "10.0.0.1", wrongPassword), errWrongPassword)
+	}
+
+	// Locked accounts are rejected without checking the password
+	called := false
+	err := service.Guard("alice", "10.0.0.2", func() error {
+		called = true
+		return nil
+	})
+	var locked *LockedError
+	require.ErrorAs(t, err, &locked)
+	assert.ErrorIs(t, err, ErrLocked)
+	assert.Equal(t, "account", locked.Scope)
+	assert.InDelta(t, time.Minute, locked.RetryAfter, float64(time.Second))
+	assert.False(t, called)
+
+	status, err := service.Status("alice")
+	require.NoError(t, err)
+	assert.True(t, status.Locked)
+	assert.Equal(t, 3, status.Failures)
+
+	// Other accounts are unaffected
+	assert.NoError(t, service.Guard("bob", "10.0.0.1", rightPassword))
+
+	require.NoError(t, service.Unlock("alice"))
+	assert.NoError(t, service.Guard("alice", "10.0.0.1", rightPassword))
+}
+
+func TestLockout_SuccessResetsFailures(t *testing.T) {
+	service := NewManager(Config{MaxAttempts: 3}, NewMemoryStore())
+
+	for i := 0; i < 2; i++ {
+		assert.Error(t, service.Guard("alice", "", wrongPassword))
+	}
+	require.NoError(t, service.Guard("alice", "", rightPassword))
+
+	status, err := service.Status("alice")
+	require.NoError(t, err)
+	assert.Zero(t, status.Failures)
+}
+
+func TestLockout_ExponentialBackoff(t *testing.T) {
+	service := NewManager(Config{MaxAttempts: 2, BaseDelay: time.Minute, MaxDelay: 5 * time.Minute}, NewMemoryStore()).(*manager)
+
+	policy := service.policy(trackedKey{limit: 2})
+	for failures, want := range map[int]time.Duration{
+		1: 0,
+		2: time.Minute,
+		3: 2 * time.Minute,
+		4: 4 * time.Minute,
+		5: 5 * time.Minute,
+		9: 5 * time.Minute,
+	} {
+		delay, _ := policy.Delay(failures)
+		assert.Equal(t, want, delay, "failures %d", failures)
+	}
+
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	require.NoError(t, service.RecordFailure("alice", ""))
+	err := service.RecordFailure("alice", "")
+	var locked *LockedError
+	require.ErrorAs(t, err, &locked)
+	assert.Equal(t, time.Minute, locked.RetryAfter)
+
+	// Failures while locked are not counted
+	now = now.Add(30 * time.Second)
+	require.ErrorAs(t, service.RecordFailure("alice", ""), &locked)
+	assert.Equal(t, 30*time.Second, locked.RetryAfter)
+
+	// Failures after the lockout extend it
+	now = now.Add(time.Minute)
+	require.ErrorAs(t, service.RecordFailure("alice", ""), &locked)
+	assert.Equal(t, 2*time.Minute, locked.RetryAfter)
+
+	status, err := service.Status("alice")
+	require.NoError(t, err)
+	assert.Equal(t, 3, status.Failures)
+}
+
+func TestLockout_PerIP(t *testing.T) {
+	service := NewManager(Config{MaxAttempts: 10, MaxAttemptsPerIP: 3}, NewMemoryStore())
+
+	// Credential stuffing spreads attempts over many accounts
+	for _, account := range []string{"alice", "bob", "carol"} {
+		assert.ErrorIs(t, service.Guard(account, "203.0.113.7", wrongPassword), errWrongPassword)
+	}
+
+	err := service.Guard("dave", "203.0.113.7", rightPassword)
+	var locked *LockedError
+	require.ErrorAs(t, err, &locked)
+	assert.Equal(t, "ip", locked.Scope)
+
+	assert.NoError(t, service.Guard("dave", "198.51.100.1", rightPassword))
+
+	require.NoError(t, service.UnlockIP("203.0.113.7"))
+	assert.NoError(t, service.Guard("dave", "203.0.113.7", rightPassword))
+}
+
+func TestLockout_WindowForgetsOldFailures(t *testing.T) {
+	service := NewManager(Config{MaxAttempts: 2, Window: time.Minute}, NewMemoryStore()).(*manager)
+
+	now := time.Now().Add(-time.Hour)
+	service.now = func() time.Time { return now }
+	assert.Error(t, service.Guard("alice", "", wrongPassword))
+
+	now = time.Now()
+	assert.Error(t, service.Guard("alice", "", wrongPassword))
+	status, err := service.Status("alice")
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Failures)
+	assert.False(t, status.Locked)
+}
+
+func TestLockout_BackoffOutlastsWindow(t *testing.T) {
+	service := NewManager(Config{MaxAttempts: 2, Window: time.Minute, BaseDelay: time.Hour, MaxDelay: 8 * time.Hour}, NewMemoryStore()).(*manager)
+
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	// Two failures lock the account for an hour
+	for i := 0; i < 2; i++ {
+		assert.ErrorIs(t, service.Guard("alice", "", wrongPassword), errWrongPassword)
+	}
+
+	// The next failure after it doubles the lockout instead of starting over
+	now = now.Add(2 * time.Hour)
+	var locked *LockedError
+	require.ErrorAs(t, service.RecordFailure("alice", ""), &locked)
+	assert.Equal(t, 2*time.Hour, locked.RetryAfter)
+
+	status, err := service.Status("alice")
+	require.NoError(t, err)
+	assert.Equal(t, 3, status.Failures)
+
+	// Once nothing failed for Decay the count starts over
+	now = now.Add(2*time.Hour + service.config.Decay)
+	assert.ErrorIs(t, service.Guard("alice", "", wrongPassword), errWrongPassword)
+
+	status, err = service.Status("alice")
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Failures)
+	assert.False(t, status.Locked)
+}
+
+func TestLockout_OtherErrorsDoNotCount(t *testing.T) {
+	service := NewManager(Config{MaxAttempts: 1}, NewMemoryStore())
+
+	errUnavailable := errors.New("database unavailable")
+	for i := 0; i < 3; i++ {
+		assert.ErrorIs(t, service.Guard("alice", "10.0.0.1", func() error { return errUnavailable }), errUnavailable)
+	}
+
+	status, err := service.Status("alice")
+	require.NoError(t, err)
+	assert.Zero(t, status.Failures)
+	assert.NoError(t, service.Guard("alice", "10.0.0.1", rightPassword))
+}
+
+func TestLockout_CountsPasswordMismatch(t *testing.T) {
+	service := NewManager(Config{MaxAttempts: 1}, NewMemoryStore())
+
+	assert.ErrorIs(t, service.Guard("alice", "", func() error { return password.ErrMismatchedPassword }), password.ErrMismatchedPassword)
+	assert.ErrorIs(t, service.Check("alice", ""), ErrLocked)
+}
+
+func TestLockout_ConcurrentAttemptsStayWithinLimit(t *testing.T) {
+	service := NewManager(Config{MaxAttempts: 3, MaxAttemptsPerIP: 100}, NewMemoryStore())
+
+	var verified int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = service.Guard("alice", "10.0.0.1", func() error {
+				atomic.AddInt32(&verified, 1)
+				time.Sleep(10 * time.Millisecond)
+				return errWrongPassword
+			})
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&verified))
+}
+
+func TestLockout_SuccessReleasesIPAttempt(t *testing.T) {
+	service := NewManager(Config{MaxAttempts: 5, MaxAttemptsPerIP: 2}, NewMemoryStore())
+
+	// Successful logins from a shared address do not lock it
+	for i := 0; i < 5; i++ {
+		require.NoError(t, service.Guard("alice", "10.0.0.1", rightPassword))
+	}
+	assert.NoError(t, service.Check("", "10.0.0.1"))
+}
@@ -0,0 +1,120 @@
+package lockout
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/upnext-fng/fulcrum/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormStore struct {
+	db database.DatabaseService
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// NewGormStore keeps failures in the lockout_records table so lockouts hold
+// across instances. Migrate Record before use.
+func NewGormStore(db database.DatabaseService) Store {
+	return &gormStore{
+		db: db,
+	}
+}
+
+func (s *gormStore) Fail(key string, now time.Time, policy Policy) (*Record, error) {
+	var record Record
+	var failErr error
+
+	err := s.db.Connection().Transaction(func(tx *gorm.DB) error {
+		// Create the row first so there is always something to lock
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&Record{Key: key, LastFailureAt: now}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("key = ?", key).
+			First(&record).Error
+		if err != nil {
+			return err
+		}
+
+		if failErr = record.fail(now, policy); failErr != nil {
+			return nil
+		}
+		return s.save(tx, &record)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if failErr != nil {
+		return &record, failErr
+	}
+
+	s.sweep(now, policy)
+	return &record, nil
+}
+
+func (s *gormStore) Release(key string, now time.Time, policy Policy) error {
+	return s.db.Connection().Transaction(func(tx *gorm.DB) error {
+		var record Record
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("key = ?", key).
+			First(&record).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		record.release(now, policy)
+		return s.save(tx, &record)
+	})
+}
+
+func (s *gormStore) save(tx *gorm.DB, record *Record) error {
+	return tx.Model(&Record{}).
+		Where("key = ?", record.Key).
+		Updates(map[string]interface{}{
+			"failures":        record.Failures,
+			"last_failure_at": record.LastFailureAt,
+			"locked_until":    record.LockedUntil,
+		}).Error
+}
+
+func (s *gormStore) Get(key string) (*Record, error) {
+	var record Record
+	if err := s.db.Connection().Where("key = ?", key).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (s *gormStore) Reset(key string) error {
+	return s.db.Connection().Where("key = ?", key).Delete(&Record{}).Error
+}
+
+// sweep deletes records whose failures and lockout have both run out
+func (s *gormStore) sweep(now time.Time, policy Policy) {
+	s.mu.Lock()
+	if now.Sub(s.lastSweep) < time.Minute {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = now
+	s.mu.Unlock()
+
+	// Best effort, the next sweep retries
+	s.db.Connection().
+		Where("(locked_until IS NULL AND last_failure_at < ?) OR (locked_until < ? AND last_failure_at < ?)", now.Add(-policy.Window), now, now.Add(-policy.Decay)).
+		Delete(&Record{})
+}
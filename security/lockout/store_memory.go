@@ -0,0 +1,90 @@
+package lockout
+
+import (
+	"sync"
+	"time"
+)
+
+type memoryStore struct {
+	mu        sync.Mutex
+	records   map[string]*Record
+	lastSweep time.Time
+}
+
+// NewMemoryStore creates a process-local store. Every instance counts
+// failures on its own, so limits are per instance.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		records: make(map[string]*Record),
+	}
+}
+
+func (s *memoryStore) Fail(key string, now time.Time, policy Policy) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now, policy)
+
+	record, ok := s.records[key]
+	if !ok {
+		record = &Record{Key: key}
+	}
+	err := record.fail(now, policy)
+	if err == nil {
+		s.records[key] = record
+	}
+
+	updated := *record
+	return &updated, err
+}
+
+func (s *memoryStore) Release(key string, now time.Time, policy Policy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[key]
+	if !ok {
+		return nil
+	}
+	record.release(now, policy)
+	if record.Failures == 0 {
+		delete(s.records, key)
+	}
+	return nil
+}
+
+func (s *memoryStore) Get(key string) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[key]
+	if !ok {
+		return nil, ErrRecordNotFound
+	}
+
+	found := *record
+	return &found, nil
+}
+
+func (s *memoryStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+// sweep drops records whose failures and lockout have both run out, at most
+// once a minute
+func (s *memoryStore) sweep(now time.Time, policy Policy) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for key, record := range s.records {
+		if policy.Expired(record, now) {
+			delete(s.records, key)
+		}
+	}
+}
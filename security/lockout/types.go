@@ -0,0 +1,119 @@
+package lockout
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Record holds the failures of one account or client address
+type Record struct {
+	Key           string     `json:"key" gorm:"primaryKey;size:255"`
+	Failures      int        `json:"failures" gorm:"not null"`
+	LastFailureAt time.Time  `json:"last_failure_at" gorm:"index"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`
+}
+
+func (Record) TableName() string {
+	return "lockout_records"
+}
+
+// fail counts a failure at now, see Store.Fail
+func (r *Record) fail(now time.Time, policy Policy) error {
+	if r.LockedAt(now) {
+		return ErrLocked
+	}
+	if policy.Expired(r, now) {
+		*r = Record{Key: r.Key}
+	}
+
+	r.Failures++
+	r.LastFailureAt = now
+	if delay, ok := policy.Delay(r.Failures); ok {
+		until := now.Add(delay)
+		r.LockedUntil = &until
+	}
+	return nil
+}
+
+// release takes back a failure counted at now, see Store.Release
+func (r *Record) release(now time.Time, policy Policy) {
+	if r.Failures > 0 {
+		r.Failures--
+	}
+
+	switch {
+	case r.Failures < policy.Limit:
+		r.LockedUntil = nil
+	case r.LockedAt(now):
+		// The failure taken back renewed a lockout that had run out; end
+		// it again but keep the record marked as locked before
+		r.LockedUntil = &now
+	}
+}
+
+// LockedAt reports whether the record is locked at t
+func (r *Record) LockedAt(t time.Time) bool {
+	return r.LockedUntil != nil && t.Before(*r.LockedUntil)
+}
+
+// Policy tells a Store how to count the failures of one key
+type Policy struct {
+	// Limit failures lock the key
+	Limit     int
+	Window    time.Duration
+	Decay     time.Duration
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// Delay is how long failures lock a key: BaseDelay once they reach Limit,
+// doubling for every further failure up to MaxDelay
+func (p Policy) Delay(failures int) (time.Duration, bool) {
+	if failures < p.Limit {
+		return 0, false
+	}
+
+	delay := p.BaseDelay
+	for i := p.Limit; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxDelay), true
+}
+
+// Expired reports whether the failures of record are forgotten at t: after
+// Window without failures, or after Decay once the record has been locked
+func (p Policy) Expired(record *Record, t time.Time) bool {
+	if record.LockedUntil != nil {
+		return t.Sub(record.LastFailureAt) > p.Decay && !record.LockedAt(t)
+	}
+	return t.Sub(record.LastFailureAt) > p.Window
+}
+
+type Status struct {
+	Failures    int        `json:"failures"`
+	Locked      bool       `json:"locked"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+}
+
+// LockedError rejects an attempt while a lockout lasts
+type LockedError struct {
+	// Scope is "account" or "ip"
+	Scope      string
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s locked, retry after %s", e.Scope, e.RetryAfter.Round(time.Second))
+}
+
+func (e *LockedError) Is(target error) bool {
+	return target == ErrLocked
+}
+
+var (
+	ErrLocked = errors.New("locked out")
+	// ErrInvalidCredentials marks verify errors that count as failed attempts
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrRecordNotFound     = errors.New("lockout record not found")
+)
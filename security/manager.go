@@ -1,6 +1,10 @@
 package security
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -17,9 +21,14 @@ type manager struct {
 	middlewareService middleware.Service
 }
 
+// NewManager creates a facade with a jwt service of its own. Services that
+// revoke tokens, such as session and oauth2, must share the facade's jwt
+// service to be honoured by its middleware; see NewSecurityService.
 func NewManager(config Config) SecurityService {
-	jwtService := jwtmod.NewJWTService(config.JWT)
+	return newManager(config, jwtmod.NewJWTService(config.JWT))
+}
 
+func newManager(config Config, jwtService jwtmod.Service) SecurityService {
 	// Extract claims parser from JWT service
 	claimsParser := jwtmod.NewClaimsParser(config.JWT)
 
@@ -38,22 +47,32 @@ func NewManager(config Config) SecurityService {
 
 // GenerateToken generates a token using strongly-typed request
 func (m *manager) GenerateToken(request TokenRequest) (TokenResponse, error) {
+	response, _, _, err := m.generateTokens(request)
+	return response, err
+}
+
+func (m *manager) generateTokens(request TokenRequest) (TokenResponse, *jwtmod.TokenPair, jwtmod.Claims, error) {
 	// Validate request
 	if err := request.Validate(); err != nil {
-		return TokenResponse{}, err
+		return TokenResponse{}, nil, jwtmod.Claims{}, err
 	}
 
 	// Convert to JWT claims
 	jwtClaims, err := convertTokenRequestToJWTClaims(request)
 	if err != nil {
-		return TokenResponse{}, err
+		return TokenResponse{}, nil, jwtmod.Claims{}, err
 	}
 
 	// Generate access token
-	signedToken, err := m.jwtService.GenerateAccessToken(jwtClaims)
+	var tokenOpts []jwtmod.TokenOption
+	if len(request.Audience) > 0 {
+		tokenOpts = append(tokenOpts, jwtmod.WithAudience(request.Audience...))
+	}
+	signedToken, err := m.jwtService.GenerateAccessToken(jwtClaims, tokenOpts...)
 	if err != nil {
-		return TokenResponse{}, err
+		return TokenResponse{}, nil, jwtmod.Claims{}, err
 	}
+	pair := &jwtmod.TokenPair{AccessToken: signedToken}
 
 	// Build response
 	response := TokenResponse{
@@ -65,11 +84,16 @@ func (m *manager) GenerateToken(request TokenRequest) (TokenResponse, error) {
 
 	// Generate refresh token if requested
 	if request.RefreshToken {
-		refreshToken, err := m.jwtService.GenerateRefreshToken(jwtClaims)
+		var refreshOpts []jwtmod.RefreshOption
+		if len(request.Audience) > 0 {
+			refreshOpts = append(refreshOpts, jwtmod.WithRefreshAudience(request.Audience...))
+		}
+		refreshToken, err := m.jwtService.GenerateRefreshToken(jwtClaims, refreshOpts...)
 		if err != nil {
-			return TokenResponse{}, err
+			return TokenResponse{}, nil, jwtmod.Claims{}, err
 		}
 		response.RefreshToken = refreshToken.Token
+		pair.RefreshToken = refreshToken
 	}
 
 	// Set scopes
@@ -77,6 +101,64 @@ func (m *manager) GenerateToken(request TokenRequest) (TokenResponse, error) {
 		response.Scope = request.Metadata.Scopes
 	}
 
+	return response, pair, jwtClaims, nil
+}
+
+// IssueTokenCookies generates tokens like GenerateToken but stores them in
+// HttpOnly cookies. The response carries the CSRF token instead of the tokens.
+func (m *manager) IssueTokenCookies(c echo.Context, request TokenRequest) (TokenResponse, error) {
+	response, pair, claims, err := m.generateTokens(request)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	return m.setTokenCookies(c, response, pair, claims)
+}
+
+// RefreshTokenCookies refreshes the tokens held in cookies. Like any unsafe
+// cookie-authenticated request it must carry the CSRF token.
+func (m *manager) RefreshTokenCookies(c echo.Context) (TokenResponse, error) {
+	refreshToken := m.middlewareService.RefreshTokenFromCookie(c)
+	if refreshToken == "" {
+		return TokenResponse{}, ErrUnauthorized
+	}
+
+	// Check CSRF before a rotating refresh consumes the token
+	validated, err := m.jwtService.ValidateToken(refreshToken)
+	if err != nil {
+		return TokenResponse{}, convertJWTError(err)
+	}
+	if err := m.middlewareService.VerifyCSRF(c, validated.Claims); err != nil {
+		return TokenResponse{}, err
+	}
+
+	pair, err := m.jwtService.RefreshTokenPair(refreshToken)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	response := TokenResponse{
+		TokenType: pair.AccessToken.TokenType,
+		ExpiresIn: pair.AccessToken.ExpiresIn,
+		ExpiresAt: pair.AccessToken.ExpiresAt,
+	}
+	return m.setTokenCookies(c, response, pair, *validated.Claims)
+}
+
+// ClearTokenCookies logs a cookie-authenticated client out
+func (m *manager) ClearTokenCookies(c echo.Context) {
+	m.middlewareService.ClearTokenCookies(c)
+}
+
+func (m *manager) setTokenCookies(c echo.Context, response TokenResponse, pair *jwtmod.TokenPair, claims jwtmod.Claims) (TokenResponse, error) {
+	csrfToken, err := m.middlewareService.SetTokenCookies(c, pair, claims)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	response.AccessToken = ""
+	response.RefreshToken = ""
+	response.CSRFToken = csrfToken
 	return response, nil
 }
 
@@ -88,66 +170,64 @@ func (m *manager) ValidateToken(request ValidationRequest) (ValidationResponse,
 	}
 
 	// Validate token
-	validatedToken, err := m.jwtService.ValidateToken(request.Token)
-	if err != nil {
-		return ValidationResponse{Valid: false}, err
+	var opts []jwtmod.ValidationOption
+	if request.SkipExpiration {
+		opts = append(opts, jwtmod.SkipExpiration())
 	}
-
-	// Parse claims
-	claims, err := m.claimsParser.ParseClaims(validatedToken.Token)
-	if err != nil {
-		return ValidationResponse{Valid: false}, err
+	if request.RequiredAudience != "" {
+		opts = append(opts, jwtmod.WithRequiredAudience(request.RequiredAudience))
 	}
-
-	// Convert to response
-	response, err := convertJWTClaimsToValidationResponse(claims)
-	if err != nil {
-		return ValidationResponse{Valid: false}, err
+	if request.RequiredIssuer != "" {
+		opts = append(opts, jwtmod.WithRequiredIssuer(request.RequiredIssuer))
 	}
-
-	// Validate required scopes
 	if len(request.RequiredScopes) > 0 {
-		for _, requiredScope := range request.RequiredScopes {
-			if !response.Metadata.HasScope(requiredScope) {
-				return ValidationResponse{Valid: false}, ErrInvalidScope
-			}
-		}
+		opts = append(opts, jwtmod.WithRequiredScopes(request.RequiredScopes...))
 	}
 
-	// Validate required audience
-	if request.RequiredAudience != "" {
-		// Check if token has required audience
-		// This would need to be implemented based on your JWT claims structure
+	validatedToken, err := m.jwtService.ValidateToken(request.Token, opts...)
+	if err != nil {
+		return ValidationResponse{Valid: false}, convertJWTError(err)
 	}
 
-	// Validate required issuer
-	if request.RequiredIssuer != "" {
-		// Check if token has required issuer
-		// This would need to be implemented based on your JWT claims structure
+	// Convert to response
+	response, err := convertJWTClaimsToValidationResponse(validatedToken.Claims)
+	if err != nil {
+		return ValidationResponse{Valid: false}, err
 	}
 
 	return response, nil
 }
 
-// RefreshAccessToken generates a new access token using a refresh token
+// RefreshAccessToken generates a new access token using a refresh token.
+// With refresh token rotation enabled the response also carries the new
+// refresh token, which replaces the one presented.
 func (m *manager) RefreshAccessToken(refreshToken string) (TokenResponse, error) {
-	// Use JWT service to refresh the access token
-	signedToken, err := m.jwtService.RefreshAccessToken(refreshToken)
+	// Use JWT service to refresh the token pair
+	pair, err := m.jwtService.RefreshTokenPair(refreshToken)
 	if err != nil {
 		return TokenResponse{}, err
 	}
 
 	// Build response
 	response := TokenResponse{
-		AccessToken: signedToken.Token,
-		TokenType:   signedToken.TokenType,
-		ExpiresIn:   signedToken.ExpiresIn,
-		ExpiresAt:   signedToken.ExpiresAt,
+		AccessToken: pair.AccessToken.Token,
+		TokenType:   pair.AccessToken.TokenType,
+		ExpiresIn:   pair.AccessToken.ExpiresIn,
+		ExpiresAt:   pair.AccessToken.ExpiresAt,
+	}
+
+	if pair.HasRefreshToken() {
+		response.RefreshToken = pair.RefreshToken.Token
 	}
 
 	return response, nil
 }
 
+// JWKSHandler serves the public signing keys at jwt.JWKSPath
+func (m *manager) JWKSHandler() echo.HandlerFunc {
+	return jwtmod.JWKSHandler(m.jwtService.Keyring())
+}
+
 func (m *manager) HashPassword(password string) (string, error) {
 	return m.passwordService.HashPassword(password)
 }
@@ -156,10 +236,26 @@ func (m *manager) VerifyPassword(hashedPassword, password string) error {
 	return m.passwordService.VerifyPassword(hashedPassword, password)
 }
 
+func (m *manager) VerifyAndRehashPassword(hashedPassword, password string) (string, error) {
+	return m.passwordService.VerifyAndRehash(hashedPassword, password)
+}
+
 func (m *manager) ValidatePassword(password string) error {
 	return m.passwordService.ValidatePassword(password)
 }
 
+func (m *manager) ValidatePasswordFor(password string, context password.PolicyContext) error {
+	return m.passwordService.ValidatePasswordFor(password, context)
+}
+
+func (m *manager) ChangePassword(userID, password string, context password.PolicyContext) (string, error) {
+	return m.passwordService.ChangePassword(userID, password, context)
+}
+
+func (m *manager) PasswordExpiry(userID string) (*password.Expiry, error) {
+	return m.passwordService.PasswordExpiry(userID)
+}
+
 func (m *manager) JWTMiddleware() echo.MiddlewareFunc {
 	return m.middlewareService.JWTMiddleware()
 }
@@ -178,161 +274,87 @@ func (m *manager) RateLimitMiddleware() echo.MiddlewareFunc {
 
 // Helper functions for conversion
 
-// convertTokenRequestToJWTClaims converts TokenRequest to JWT Claims
+// convertTokenRequestToJWTClaims converts TokenRequest to JWT Claims. User
+// claims and token metadata share the metadata claim and custom claims are
+// top-level claims, each keyed by their JSON names, so that they decode back
+// without loss.
 func convertTokenRequestToJWTClaims(request TokenRequest) (jwtmod.Claims, error) {
 	claims := jwtmod.Claims{
-		UserID: request.UserClaims.UserID,
-	}
-
-	// Set metadata
-	if claims.Metadata == nil {
-		claims.Metadata = make(map[string]interface{})
-	}
-
-	// Add user claims to metadata
-	claims.Metadata["username"] = request.UserClaims.Username
-	claims.Metadata["email"] = request.UserClaims.Email
-	claims.Metadata["role"] = request.UserClaims.Role
-	claims.Metadata["first_name"] = request.UserClaims.FirstName
-	claims.Metadata["last_name"] = request.UserClaims.LastName
-	claims.Metadata["display_name"] = request.UserClaims.DisplayName
-	claims.Metadata["avatar_url"] = request.UserClaims.AvatarURL
-	claims.Metadata["timezone"] = request.UserClaims.Timezone
-	claims.Metadata["locale"] = request.UserClaims.Locale
-	claims.Metadata["permissions"] = request.UserClaims.Permissions
-	claims.Metadata["groups"] = request.UserClaims.Groups
-	claims.Metadata["organization_id"] = request.UserClaims.OrganizationID
-	claims.Metadata["tenant_id"] = request.UserClaims.TenantID
-	claims.Metadata["ip_address"] = request.UserClaims.IPAddress
-	claims.Metadata["user_agent"] = request.UserClaims.UserAgent
-
-	// Add token metadata
-	claims.Metadata["purpose"] = request.Metadata.Purpose
-	claims.Metadata["source"] = request.Metadata.Source
-	claims.Metadata["environment"] = request.Metadata.Environment
-	claims.Metadata["created_at"] = request.Metadata.CreatedAt
-	claims.Metadata["created_by"] = request.Metadata.CreatedBy
-	claims.Metadata["request_id"] = request.Metadata.RequestID
-	claims.Metadata["trace_id"] = request.Metadata.TraceID
-	claims.Metadata["requires_mfa"] = request.Metadata.RequiresMFA
-	claims.Metadata["mfa_verified"] = request.Metadata.MFAVerified
-	claims.Metadata["rate_limit"] = request.Metadata.RateLimit
-	claims.Metadata["requests_used"] = request.Metadata.RequestsUsed
-	claims.Metadata["feature_flags"] = request.Metadata.FeatureFlags
-	claims.Metadata["experiments"] = request.Metadata.Experiments
-
-	// Set custom claims
-	if claims.Custom == nil {
-		claims.Custom = make(map[string]interface{})
-	}
-	claims.Custom["app_version"] = request.CustomClaims.AppVersion
-	claims.Custom["api_version"] = request.CustomClaims.APIVersion
-	claims.Custom["subscription_tier"] = request.CustomClaims.SubscriptionTier
-	claims.Custom["subscription_expiry"] = request.CustomClaims.SubscriptionExpiry
-	claims.Custom["theme"] = request.CustomClaims.Theme
-	claims.Custom["language"] = request.CustomClaims.Language
-	claims.Custom["preferences"] = request.CustomClaims.Preferences
-	claims.Custom["external_ids"] = request.CustomClaims.ExternalIDs
-	claims.Custom["data"] = request.CustomClaims.Data
-
-	// Set other fields
-	if len(request.Metadata.Scopes) > 0 {
-		claims.Scopes = request.Metadata.Scopes
+		UserID:    request.UserClaims.UserID,
+		Issuer:    request.Issuer,
+		SessionID: request.UserClaims.SessionID,
+		DeviceID:  request.UserClaims.DeviceID,
+		ClientID:  request.UserClaims.ClientID,
+		Scopes:    request.Metadata.Scopes,
+		Metadata:  make(map[string]interface{}),
 	}
-	if request.UserClaims.SessionID != "" {
-		claims.SessionID = request.UserClaims.SessionID
+
+	// Identifiers and scopes already have claims of their own
+	user := request.UserClaims
+	user.SessionID, user.DeviceID, user.ClientID = "", "", ""
+	metadata := request.Metadata
+	metadata.Scopes = nil
+
+	if err := encodeClaimsMap(user, claims.Metadata); err != nil {
+		return jwtmod.Claims{}, err
 	}
-	if request.UserClaims.DeviceID != "" {
-		claims.DeviceID = request.UserClaims.DeviceID
+	delete(claims.Metadata, "user_id")
+	if err := encodeClaimsMap(metadata, claims.Metadata); err != nil {
+		return jwtmod.Claims{}, err
 	}
-	if request.UserClaims.ClientID != "" {
-		claims.ClientID = request.UserClaims.ClientID
+
+	claims.Custom = make(map[string]interface{})
+	if err := encodeClaimsMap(request.CustomClaims, claims.Custom); err != nil {
+		return jwtmod.Claims{}, err
 	}
 
 	return claims, nil
 }
 
+// UserClaimsFromJWT rebuilds the UserClaims of a token issued by
+// GenerateToken from its validated jwt claims
+func UserClaimsFromJWT(claims *jwtmod.Claims) (UserClaims, error) {
+	response, err := convertJWTClaimsToValidationResponse(claims)
+	if err != nil {
+		return UserClaims{}, err
+	}
+	return response.UserClaims, nil
+}
+
+// convertJWTError maps jwt errors onto the facade's errors, keeping the
+// original in the chain
+func convertJWTError(err error) error {
+	switch {
+	case errors.Is(err, jwtmod.ErrTokenExpired):
+		return fmt.Errorf("%w: %w", ErrTokenExpired, err)
+	case errors.Is(err, jwtmod.ErrInvalidAudience):
+		return fmt.Errorf("%w: %w", ErrInvalidAudience, err)
+	case errors.Is(err, jwtmod.ErrInvalidIssuer):
+		return fmt.Errorf("%w: %w", ErrInvalidIssuer, err)
+	case errors.Is(err, jwtmod.ErrInsufficientScope):
+		return fmt.Errorf("%w: %w", ErrInvalidScope, err)
+	default:
+		return fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+}
+
 // convertJWTClaimsToValidationResponse converts JWT Claims to ValidationResponse
 func convertJWTClaimsToValidationResponse(claims *jwtmod.Claims) (ValidationResponse, error) {
 	var response ValidationResponse
 
+	if err := decodeClaimsMap("metadata", claims.Metadata, &response.UserClaims); err != nil {
+		return response, err
+	}
+	if err := decodeClaimsMap("metadata", claims.Metadata, &response.Metadata); err != nil {
+		return response, err
+	}
+	if err := decodeClaimsMap("custom", claims.Custom, &response.CustomClaims); err != nil {
+		return response, err
+	}
+
 	response.Valid = true
 	response.UserClaims.UserID = claims.UserID
 
-	// Extract user claims from metadata
-	if claims.Metadata != nil {
-		if username, ok := claims.Metadata["username"].(string); ok {
-			response.UserClaims.Username = username
-		}
-		if email, ok := claims.Metadata["email"].(string); ok {
-			response.UserClaims.Email = email
-		}
-		if role, ok := claims.Metadata["role"].(string); ok {
-			response.UserClaims.Role = role
-		}
-		if firstName, ok := claims.Metadata["first_name"].(string); ok {
-			response.UserClaims.FirstName = firstName
-		}
-		if lastName, ok := claims.Metadata["last_name"].(string); ok {
-			response.UserClaims.LastName = lastName
-		}
-		if displayName, ok := claims.Metadata["display_name"].(string); ok {
-			response.UserClaims.DisplayName = displayName
-		}
-		if avatarURL, ok := claims.Metadata["avatar_url"].(string); ok {
-			response.UserClaims.AvatarURL = avatarURL
-		}
-		if timezone, ok := claims.Metadata["timezone"].(string); ok {
-			response.UserClaims.Timezone = timezone
-		}
-		if locale, ok := claims.Metadata["locale"].(string); ok {
-			response.UserClaims.Locale = locale
-		}
-		if permissions, ok := claims.Metadata["permissions"].([]interface{}); ok {
-			for _, p := range permissions {
-				if perm, ok := p.(string); ok {
-					response.UserClaims.Permissions = append(response.UserClaims.Permissions, perm)
-				}
-			}
-		}
-		if groups, ok := claims.Metadata["groups"].([]interface{}); ok {
-			for _, g := range groups {
-				if group, ok := g.(string); ok {
-					response.UserClaims.Groups = append(response.UserClaims.Groups, group)
-				}
-			}
-		}
-		if orgID, ok := claims.Metadata["organization_id"].(string); ok {
-			response.UserClaims.OrganizationID = orgID
-		}
-		if tenantID, ok := claims.Metadata["tenant_id"].(string); ok {
-			response.UserClaims.TenantID = tenantID
-		}
-		if ipAddress, ok := claims.Metadata["ip_address"].(string); ok {
-			response.UserClaims.IPAddress = ipAddress
-		}
-		if userAgent, ok := claims.Metadata["user_agent"].(string); ok {
-			response.UserClaims.UserAgent = userAgent
-		}
-
-		// Extract metadata
-		if purpose, ok := claims.Metadata["purpose"].(string); ok {
-			response.Metadata.Purpose = purpose
-		}
-		if source, ok := claims.Metadata["source"].(string); ok {
-			response.Metadata.Source = source
-		}
-		if environment, ok := claims.Metadata["environment"].(string); ok {
-			response.Metadata.Environment = environment
-		}
-		if requiresMFA, ok := claims.Metadata["requires_mfa"].(bool); ok {
-			response.Metadata.RequiresMFA = requiresMFA
-		}
-		if mfaVerified, ok := claims.Metadata["mfa_verified"].(bool); ok {
-			response.Metadata.MFAVerified = mfaVerified
-		}
-	}
-
 	// Set session info
 	response.UserClaims.SessionID = claims.SessionID
 	response.UserClaims.DeviceID = claims.DeviceID
@@ -352,3 +374,37 @@ func convertJWTClaimsToValidationResponse(claims *jwtmod.Claims) (ValidationResp
 
 	return response, nil
 }
+
+// encodeClaimsMap adds the JSON fields of value to claims
+func encodeClaimsMap(value interface{}, claims map[string]interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return jwtmod.NewValidationError("claims", err.Error(), jwtmod.ErrInvalidClaims)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(&claims)
+}
+
+// decodeClaimsMap decodes a claims map into target; claims of the wrong type
+// are reported as a ValidationError rather than silently dropped
+func decodeClaimsMap(field string, claims map[string]interface{}, target interface{}) error {
+	if len(claims) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return jwtmod.NewValidationError(field, err.Error(), jwtmod.ErrInvalidClaims)
+	}
+
+	if err := json.Unmarshal(data, target); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			field = field + "." + typeErr.Field
+		}
+		return jwtmod.NewValidationError(field, err.Error(), jwtmod.ErrInvalidClaims)
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package security
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/upnext-fng/fulcrum/security/jwt"
+	"github.com/upnext-fng/fulcrum/security/middleware"
+	"go.uber.org/fx"
+)
+
+// cookieJar keeps the cookies a browser would send back
+type cookieJar map[string]*http.Cookie
+
+func (j cookieJar) store(rec *httptest.ResponseRecorder) {
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.MaxAge < 0 {
+			delete(j, cookie.Name)
+			continue
+		}
+		j[cookie.Name] = cookie
+	}
+}
+
+func (j cookieJar) expire(now time.Time) {
+	for name, cookie := range j {
+		if !cookie.Expires.IsZero() && !now.Before(cookie.Expires) {
+			delete(j, name)
+		}
+	}
+}
+
+func (j cookieJar) request(method, target, csrfToken string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	for _, cookie := range j {
+		req.AddCookie(cookie)
+	}
+	if csrfToken != "" {
+		req.Header.Set("X-CSRF-Token", csrfToken)
+	}
+	return req
+}
+
+func TestCookieRefresh_KeepsCSRFCookieAfterAccessTokenExpires(t *testing.T) {
+	now := time.Now()
+	service := NewManager(Config{
+		JWT: jwt.Config{
+			Secret:          "test-secret-key-123",
+			AccessTokenTTL:  time.Minute,
+			RefreshTokenTTL: 24 * time.Hour,
+			Clock:           jwt.ClockFunc(func() time.Time { return now }),
+		},
+		Middleware: middleware.Config{Cookie: middleware.CookieConfig{Enabled: true}},
+	})
+
+	e := echo.New()
+	jar := cookieJar{}
+
+	rec := httptest.NewRecorder()
+	login, err := service.IssueTokenCookies(e.NewContext(jar.request(http.MethodPost, "/login", ""), rec), TokenRequest{
+		UserClaims:   UserClaims{UserID: "test-user-123"},
+		RefreshToken: true,
+	})
+	require.NoError(t, err)
+	jar.store(rec)
+	require.Contains(t, jar, "csrf_token")
+
+	refresh := func() (TokenResponse, error) {
+		rec := httptest.NewRecorder()
+		response, err := service.RefreshTokenCookies(e.NewContext(jar.request(http.MethodPost, "/refresh", login.CSRFToken), rec))
+		jar.store(rec)
+		return response, err
+	}
+
+	// A refresh without a new refresh token keeps the CSRF cookie as it is
+	response, err := refresh()
+	require.NoError(t, err)
+	assert.Equal(t, login.CSRFToken, response.CSRFToken)
+	assert.Equal(t, login.CSRFToken, jar["csrf_token"].Value)
+
+	// Once the access token expired the refresh cookie still works
+	now = now.Add(2 * time.Minute)
+	jar.expire(now)
+	require.NotContains(t, jar, "access_token")
+	require.Contains(t, jar, "csrf_token")
+
+	response, err = refresh()
+	require.NoError(t, err)
+	assert.Equal(t, login.CSRFToken, response.CSRFToken)
+	assert.Contains(t, jar, "access_token")
+}
+
+func TestModule_SharesJWTService(t *testing.T) {
+	var service SecurityService
+	var jwtService jwt.Service
+	app := fx.New(
+		fx.NopLogger,
+		fx.Provide(func() Config {
+			return Config{JWT: jwt.Config{Secret: "test-secret-key-123", AccessTokenTTL: time.Hour}}
+		}),
+		Module,
+		fx.Populate(&service, &jwtService),
+	)
+	require.NoError(t, app.Err())
+
+	token, err := service.GenerateToken(TokenRequest{UserClaims: UserClaims{UserID: "test-user-123", SessionID: "session-1"}})
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}, service.JWTMiddleware())
+	get := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token.AccessToken)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	assert.Equal(t, http.StatusNoContent, get())
+
+	// Sessions and oauth2 revoke through the shared jwt service
+	require.NoError(t, jwtService.RevokeSession("session-1"))
+	assert.Equal(t, http.StatusUnauthorized, get())
+}
+
+func newTestManager() *manager {
+	return NewManager(Config{JWT: jwt.Config{
+		Secret:          "test-secret-key-123",
+		AccessTokenTTL:  time.Hour,
+		RefreshTokenTTL: 24 * time.Hour,
+	}}).(*manager)
+}
+
+func TestGenerateToken_ClaimsRoundTrip(t *testing.T) {
+	service := newTestManager()
+
+	lastLogin := time.Date(2030, 1, 1, 8, 0, 0, 0, time.UTC)
+	request := TokenRequest{
+		UserClaims: UserClaims{
+			UserID:         "user-1",
+			Username:       "alice",
+			Email:          "alice@example.com",
+			Role:           "admin",
+			LastLoginAt:    &lastLogin,
+			Permissions:    []string{"users:read", "users:write"},
+			OrganizationID: "org-1",
+			SessionID:      "session-1",
+			DeviceID:       "device-1",
+			ClientID:       "web",
+			IPAddress:      "192.0.2.1",
+		},
+		Metadata: TokenMetadata{
+			Purpose:      "login",
+			MFAVerified:  true,
+			Scopes:       []string{"read", "write"},
+			CreatedAt:    lastLogin,
+			RateLimit:    100,
+			FeatureFlags: map[string]bool{"beta": true},
+		},
+		CustomClaims: CustomClaims{
+			AppVersion:  "2.1.0",
+			Preferences: map[string]string{"theme": "dark"},
+			Data:        map[string]interface{}{"plan": "pro"},
+		},
+		RefreshToken: true,
+	}
+
+	token, err := service.GenerateToken(request)
+	require.NoError(t, err)
+	refreshed, err := service.RefreshAccessToken(token.RefreshToken)
+	require.NoError(t, err)
+
+	for _, accessToken := range []string{token.AccessToken, refreshed.AccessToken} {
+		response, err := service.ValidateToken(ValidationRequest{Token: accessToken})
+		require.NoError(t, err)
+		assert.True(t, response.Valid)
+		assert.Equal(t, request.UserClaims, response.UserClaims)
+		assert.Equal(t, request.Metadata, response.Metadata)
+		assert.Equal(t, request.CustomClaims, response.CustomClaims)
+		assert.Equal(t, request.Metadata.Scopes, response.Scopes)
+	}
+}
+
+func TestGenerateToken_CustomClaimsCannotOverrideReservedClaims(t *testing.T) {
+	service := newTestManager()
+
+	// Reserved names inside the custom data stay nested under it
+	token, err := service.GenerateToken(TokenRequest{
+		UserClaims:   UserClaims{UserID: "user-1"},
+		Metadata:     TokenMetadata{Scopes: []string{"read"}},
+		CustomClaims: CustomClaims{Data: map[string]interface{}{"sub": "mallory", "scope": "admin"}},
+	})
+	require.NoError(t, err)
+
+	validated, err := service.jwtService.ValidateToken(token.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", validated.Claims.Subject)
+	assert.Equal(t, []string{"read"}, validated.Claims.Scopes)
+
+	response, err := service.ValidateToken(ValidationRequest{Token: token.AccessToken})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"sub": "mallory", "scope": "admin"}, response.CustomClaims.Data)
+
+	// Top-level custom claims never replace the standard ones
+	signed, err := service.jwtService.GenerateAccessToken(jwt.Claims{
+		UserID: "user-1",
+		Scopes: []string{"read"},
+		Custom: map[string]interface{}{
+			"user_id":     "mallory",
+			"sub":         "mallory",
+			"scopes":      []string{"admin"},
+			"scope":       "admin",
+			"session_id":  "session-2",
+			"app_version": "2.1.0",
+		},
+	}, jwt.WithScope("read"))
+	require.NoError(t, err)
+
+	validated, err = service.jwtService.ValidateToken(signed.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", validated.Claims.Subject)
+	assert.Equal(t, map[string]interface{}{"app_version": "2.1.0"}, validated.Claims.Custom)
+
+	response, err = service.ValidateToken(ValidationRequest{Token: signed.Token})
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", response.UserClaims.UserID)
+	assert.Empty(t, response.UserClaims.SessionID)
+	assert.Equal(t, []string{"read"}, response.Scopes)
+	assert.Equal(t, "2.1.0", response.CustomClaims.AppVersion)
+
+	_, err = service.ValidateToken(ValidationRequest{Token: signed.Token, RequiredScopes: []string{"admin"}})
+	assert.ErrorIs(t, err, ErrInvalidScope)
+}
+
+func TestValidateToken_MalformedMetadata(t *testing.T) {
+	service := newTestManager()
+
+	for name, test := range map[string]struct {
+		claims jwt.Claims
+		field  string
+	}{
+		"user claim": {
+			claims: jwt.Claims{UserID: "user-1", Metadata: map[string]interface{}{"permissions": "users:read"}},
+			field:  "metadata.permissions",
+		},
+		"token metadata": {
+			claims: jwt.Claims{UserID: "user-1", Metadata: map[string]interface{}{"created_at": "yesterday"}},
+			field:  "metadata",
+		},
+		"custom claim": {
+			claims: jwt.Claims{UserID: "user-1", Custom: map[string]interface{}{"preferences": "dark"}},
+			field:  "custom.preferences",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			signed, err := service.jwtService.GenerateAccessToken(test.claims)
+			require.NoError(t, err)
+
+			response, err := service.ValidateToken(ValidationRequest{Token: signed.Token})
+			assert.False(t, response.Valid)
+			var validationErr *jwt.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.ErrorIs(t, err, jwt.ErrInvalidClaims)
+			assert.Equal(t, test.field, validationErr.Field)
+		})
+	}
+}
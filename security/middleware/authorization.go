@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/upnext-fng/fulcrum/security/jwt"
+)
+
+// ClaimsContextKey is where JWTMiddleware stores the validated *jwt.Claims
+const ClaimsContextKey = "claims"
+
+// RequireScopes allows requests whose token carries every scope
+func RequireScopes(scopes ...string) echo.MiddlewareFunc {
+	return authorize(func(c echo.Context, claims *jwt.Claims) error {
+		missing := missingValues(claims.Scopes, scopes)
+		if len(missing) == 0 {
+			return nil
+		}
+		return bearerChallenge(c, "", http.StatusForbidden, BearerInsufficientScope, AuthError{
+			Message: "insufficient scope",
+			Missing: missing,
+		}, scopes)
+	})
+}
+
+// RequireAnyScope allows requests whose token carries at least one scope
+func RequireAnyScope(scopes ...string) echo.MiddlewareFunc {
+	return authorize(func(c echo.Context, claims *jwt.Claims) error {
+		if len(scopes) == 0 || containsAny(claims.Scopes, scopes) {
+			return nil
+		}
+		return bearerChallenge(c, "", http.StatusForbidden, BearerInsufficientScope, AuthError{
+			Message: "insufficient scope",
+			Missing: scopes,
+		}, scopes)
+	})
+}
+
+// RequireRole allows requests from users holding one of roles
+func RequireRole(roles ...string) echo.MiddlewareFunc {
+	return authorize(func(c echo.Context, claims *jwt.Claims) error {
+		if len(roles) == 0 || containsAny(ClaimsRoles(claims), roles) {
+			return nil
+		}
+		return forbidden(AuthError{
+			Message:       "insufficient role",
+			RequiredRoles: roles,
+		})
+	})
+}
+
+// RequirePermission allows requests from users holding every permission
+func RequirePermission(permissions ...string) echo.MiddlewareFunc {
+	return authorize(func(c echo.Context, claims *jwt.Claims) error {
+		missing := missingValues(ClaimsPermissions(claims), permissions)
+		if len(missing) == 0 {
+			return nil
+		}
+		return forbidden(AuthError{
+			Message:            "insufficient permissions",
+			MissingPermissions: missing,
+		})
+	})
+}
+
+// RequireGroup allows requests from members of one of groups
+func RequireGroup(groups ...string) echo.MiddlewareFunc {
+	return authorize(func(c echo.Context, claims *jwt.Claims) error {
+		if len(groups) == 0 || containsAny(ClaimsGroups(claims), groups) {
+			return nil
+		}
+		return forbidden(AuthError{
+			Message:        "insufficient group membership",
+			RequiredGroups: groups,
+		})
+	})
+}
+
+// ClaimsRoles returns the role written by the security facade along with any
+// roles listed under the "roles" metadata key
+func ClaimsRoles(claims *jwt.Claims) []string {
+	var roles []string
+	if role := claims.GetMetadataString("role"); role != "" {
+		roles = append(roles, role)
+	}
+	return append(roles, metadataStrings(claims, "roles")...)
+}
+
+// ClaimsPermissions returns the permissions stored in token metadata
+func ClaimsPermissions(claims *jwt.Claims) []string {
+	return metadataStrings(claims, "permissions")
+}
+
+// ClaimsGroups returns the groups stored in token metadata
+func ClaimsGroups(claims *jwt.Claims) []string {
+	return metadataStrings(claims, "groups")
+}
+
+// authorize runs check against the claims set by JWTMiddleware
+func authorize(check func(echo.Context, *jwt.Claims) error) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims, ok := ClaimsFrom(c)
+			if !ok {
+				return bearerChallenge(c, "", http.StatusUnauthorized, "", AuthError{Message: "missing authorization token"}, nil)
+			}
+
+			if err := check(c, claims); err != nil {
+				return err
+			}
+			return next(c)
+		}
+	}
+}
+
+func forbidden(body AuthError) error {
+	body.Error = "forbidden"
+	return echo.NewHTTPError(http.StatusForbidden, body)
+}
+
+func metadataStrings(claims *jwt.Claims, key string) []string {
+	switch values := claims.Metadata[key].(type) {
+	case []string:
+		return values
+	case []interface{}:
+		result := make([]string, 0, len(values))
+		for _, value := range values {
+			if str, ok := value.(string); ok {
+				result = append(result, str)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+func missingValues(have, want []string) []string {
+	set := make(map[string]bool, len(have))
+	for _, value := range have {
+		set[value] = true
+	}
+
+	var missing []string
+	for _, value := range want {
+		if !set[value] {
+			missing = append(missing, value)
+		}
+	}
+	return missing
+}
+
+func containsAny(have, want []string) bool {
+	return len(missingValues(have, want)) < len(want)
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/upnext-fng/fulcrum/security/jwt"
+)
+
+func authorizeRequest(claims *jwt.Claims, middleware echo.MiddlewareFunc) *httptest.ResponseRecorder {
+	e := echo.New()
+	e.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}, func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if claims != nil {
+				c.Set(ClaimsContextKey, claims)
+			}
+			return next(c)
+		}
+	}, middleware)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	return rec
+}
+
+func TestAuthorization(t *testing.T) {
+	claims := &jwt.Claims{
+		UserID: "test-user-123",
+		Scopes: []string{"orders:read"},
+		Metadata: map[string]interface{}{
+			"role":        "editor",
+			"permissions": []interface{}{"orders.view", "orders.edit"},
+			"groups":      []interface{}{"emea"},
+		},
+	}
+
+	assert.Equal(t, http.StatusNoContent, authorizeRequest(claims, RequireScopes("orders:read")).Code)
+	assert.Equal(t, http.StatusNoContent, authorizeRequest(claims, RequireAnyScope("orders:write", "orders:read")).Code)
+	assert.Equal(t, http.StatusNoContent, authorizeRequest(claims, RequireRole("admin", "editor")).Code)
+	assert.Equal(t, http.StatusNoContent, authorizeRequest(claims, RequirePermission("orders.view", "orders.edit")).Code)
+	assert.Equal(t, http.StatusNoContent, authorizeRequest(claims, RequireGroup("emea")).Code)
+
+	rec := authorizeRequest(claims, RequireScopes("orders:read", "orders:write"))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Header().Get(echo.HeaderWWWAuthenticate), `error="insufficient_scope"`)
+	assert.JSONEq(t, `{"message":"insufficient scope","error":"insufficient_scope","missing_scopes":["orders:write"]}`, rec.Body.String())
+
+	rec = authorizeRequest(claims, RequirePermission("orders.view", "orders.delete"))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.JSONEq(t, `{"message":"insufficient permissions","error":"forbidden","missing_permissions":["orders.delete"]}`, rec.Body.String())
+
+	rec = authorizeRequest(claims, RequireRole("admin"))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.JSONEq(t, `{"message":"insufficient role","error":"forbidden","required_roles":["admin"]}`, rec.Body.String())
+
+	assert.Equal(t, http.StatusUnauthorized, authorizeRequest(nil, RequireRole("admin")).Code)
+}
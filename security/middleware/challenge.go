@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/upnext-fng/fulcrum/security/jwt"
+)
+
+// Bearer token error codes from RFC 6750 section 3.1
+const (
+	BearerInvalidRequest    = "invalid_request"
+	BearerInvalidToken      = "invalid_token"
+	BearerInsufficientScope = "insufficient_scope"
+)
+
+// AuthError is the response body of a rejected request. Message keeps the
+// shape of echo's default error body.
+type AuthError struct {
+	Message string        `json:"message"`
+	Error   string        `json:"error,omitempty"`
+	Code    jwt.ErrorCode `json:"code,omitempty"`
+	Missing []string      `json:"missing_scopes,omitempty"`
+
+	// Authorization failures
+	MissingPermissions []string `json:"missing_permissions,omitempty"`
+	RequiredRoles      []string `json:"required_roles,omitempty"`
+	RequiredGroups     []string `json:"required_groups,omitempty"`
+}
+
+func (m *manager) bearerChallenge(c echo.Context, status int, bearerErr string, body AuthError, scopes []string) error {
+	return bearerChallenge(c, m.config.Realm, status, bearerErr, body, scopes)
+}
+
+// bearerChallenge rejects the request with a WWW-Authenticate header. An
+// empty bearerErr means no credentials were presented.
+func bearerChallenge(c echo.Context, realm string, status int, bearerErr string, body AuthError, scopes []string) error {
+	params := make([]string, 0, 4)
+	if realm != "" {
+		params = append(params, fmt.Sprintf("realm=%q", realm))
+	}
+	if bearerErr != "" {
+		params = append(params, fmt.Sprintf("error=%q", bearerErr))
+		params = append(params, fmt.Sprintf("error_description=%q", body.Message))
+	}
+	if len(scopes) > 0 {
+		params = append(params, fmt.Sprintf("scope=%q", strings.Join(scopes, " ")))
+	}
+
+	challenge := "Bearer"
+	if len(params) > 0 {
+		challenge += " " + strings.Join(params, ", ")
+	}
+	c.Response().Header().Set(echo.HeaderWWWAuthenticate, challenge)
+
+	body.Error = bearerErr
+	return echo.NewHTTPError(status, body)
+}
+
+// rejectToken maps a validation error to its RFC 6750 response
+func (m *manager) rejectToken(c echo.Context, err error) error {
+	body := AuthError{
+		Message: "invalid or expired token",
+		Code:    jwt.ErrorCodeOf(err),
+	}
+
+	var validationErr *jwt.ValidationError
+	if !errors.As(err, &validationErr) {
+		return m.bearerChallenge(c, http.StatusUnauthorized, BearerInvalidToken, body, nil)
+	}
+
+	body.Message = validationErr.Message
+	if validationErr.Code == jwt.CodeScopeMissing {
+		body.Missing = validationErr.Missing
+		return m.bearerChallenge(c, http.StatusForbidden, BearerInsufficientScope, body, m.config.RequiredScopes)
+	}
+	return m.bearerChallenge(c, http.StatusUnauthorized, BearerInvalidToken, body, nil)
+}
@@ -2,10 +2,42 @@ package middleware
 
 import (
 	"github.com/labstack/echo/v4"
+	httpmiddleware "github.com/upnext-fng/fulcrum/http/middleware"
 	"github.com/upnext-fng/fulcrum/security/jwt"
 )
 
 type Config struct {
 	Skipper   func(echo.Context) bool
 	JWTConfig jwt.Config `mapstructure:"jwt"`
+
+	// TokenLookup lists where tokens are read from, tried in order, see
+	// ParseTokenLookup. Defaults to DefaultTokenLookup. Cookie sources
+	// require Cookie to be enabled, whose CSRF protection then covers them.
+	// TokenExtractors replaces it with custom extractors, which are not
+	// CSRF protected.
+	TokenLookup     string           `mapstructure:"token_lookup"`
+	TokenExtractors []TokenExtractor `mapstructure:"-"`
+
+	// Cookie issues tokens as cookies and reads them back, with CSRF
+	// protection. The access token cookie is looked up after TokenLookup.
+	Cookie CookieConfig `mapstructure:"cookie"`
+
+	// CORS is the policy of CORSMiddleware; by default any origin is allowed
+	// without credentials
+	CORS httpmiddleware.CORSConfig `mapstructure:"cors"`
+
+	// RateLimit configures RateLimitMiddleware, which passes everything
+	// through unless enabled
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+
+	// Realm is announced in WWW-Authenticate challenges
+	Realm string `mapstructure:"realm"`
+
+	// Required claims, on top of the issuer and audience from JWTConfig
+	RequiredIssuer   string   `mapstructure:"required_issuer"`
+	RequiredAudience string   `mapstructure:"required_audience"`
+	RequiredScopes   []string `mapstructure:"required_scopes"`
+
+	// SessionValidator rejects tokens whose session has ended
+	SessionValidator SessionValidator `mapstructure:"-"`
 }
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+	"github.com/upnext-fng/fulcrum/security/jwt"
+)
+
+// Keys under which JWTMiddleware also stores the principal in echo.Context,
+// kept for handlers written against the untyped values
+const (
+	UserIDContextKey = "user_id"
+	TokenContextKey  = "token"
+)
+
+type principalKey struct{}
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	UserID string
+	Token  string
+	Claims *jwt.Claims
+}
+
+// SetPrincipal records the authenticated caller on the echo context and the
+// request context, and returns the AuthContext to pass on to the next handler.
+// Authentication middlewares other than JWTMiddleware use it as well.
+func SetPrincipal(c echo.Context, claims *jwt.Claims, token string) *AuthContext {
+	principal := &Principal{
+		UserID: claims.UserID,
+		Token:  token,
+		Claims: claims,
+	}
+
+	c.Set(UserIDContextKey, principal.UserID)
+	c.Set(TokenContextKey, principal.Token)
+	c.Set(ClaimsContextKey, principal.Claims)
+
+	request := c.Request()
+	c.SetRequest(request.WithContext(WithPrincipal(request.Context(), principal)))
+
+	return &AuthContext{
+		UserID:  principal.UserID,
+		Token:   principal.Token,
+		Claims:  principal.Claims,
+		Context: c,
+	}
+}
+
+// WithPrincipal returns a copy of ctx carrying principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the caller stored in ctx by the authentication
+// middleware, for code below the handler that has no echo.Context
+func PrincipalFrom(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// UserIDFrom returns the authenticated user ID stored in ctx
+func UserIDFrom(ctx context.Context) (string, bool) {
+	principal, ok := PrincipalFrom(ctx)
+	if !ok || principal.UserID == "" {
+		return "", false
+	}
+	return principal.UserID, true
+}
+
+// ClaimsFromContext returns the validated claims stored in ctx
+func ClaimsFromContext(ctx context.Context) (*jwt.Claims, bool) {
+	principal, ok := PrincipalFrom(ctx)
+	if !ok || principal.Claims == nil {
+		return nil, false
+	}
+	return principal.Claims, true
+}
+
+// ClaimsFrom returns the validated claims of an authenticated request
+func ClaimsFrom(c echo.Context) (*jwt.Claims, bool) {
+	claims, ok := c.Get(ClaimsContextKey).(*jwt.Claims)
+	return claims, ok && claims != nil
+}
+
+// AuthContextFrom returns the AuthContext of an authenticated request, also
+// when later middleware replaced the context passed to the handler
+func AuthContextFrom(c echo.Context) (*AuthContext, bool) {
+	if authContext, ok := c.(*AuthContext); ok {
+		return authContext, true
+	}
+
+	claims, ok := ClaimsFrom(c)
+	if !ok {
+		return nil, false
+	}
+	token, _ := c.Get(TokenContextKey).(string)
+	return &AuthContext{
+		UserID:  claims.UserID,
+		Token:   token,
+		Claims:  claims,
+		Context: c,
+	}, true
+}
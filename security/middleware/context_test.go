@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/upnext-fng/fulcrum/security/jwt"
+)
+
+func TestJWTMiddleware_PropagatesPrincipal(t *testing.T) {
+	config := Config{JWTConfig: jwt.Config{Secret: "test-secret-key-123", AccessTokenTTL: time.Hour}}
+	jwtService := jwt.NewJWTService(config.JWTConfig)
+
+	// serviceLayer stands in for code that only sees context.Context
+	var userID string
+	serviceLayer := func(ctx context.Context) {
+		userID, _ = UserIDFrom(ctx)
+	}
+
+	e := echo.New()
+	e.GET("/", func(c echo.Context) error {
+		claims, ok := ClaimsFrom(c)
+		require.True(t, ok)
+		assert.Equal(t, "test-user-123", claims.UserID)
+
+		authContext, ok := c.(*AuthContext)
+		require.True(t, ok)
+		assert.Equal(t, "test-user-123", authContext.UserID)
+		assert.NotEmpty(t, authContext.Token)
+
+		serviceLayer(c.Request().Context())
+		return c.NoContent(http.StatusNoContent)
+	}, NewService(config, jwtService).JWTMiddleware())
+
+	token, err := jwtService.GenerateAccessToken(jwt.Claims{UserID: "test-user-123"})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, serve(e, token.Token).Code)
+	assert.Equal(t, "test-user-123", userID)
+}
+
+func TestContextAccessors_Unauthenticated(t *testing.T) {
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+
+	_, ok := ClaimsFrom(c)
+	assert.False(t, ok)
+	_, ok = AuthContextFrom(c)
+	assert.False(t, ok)
+	_, ok = UserIDFrom(c.Request().Context())
+	assert.False(t, ok)
+	_, ok = ClaimsFromContext(context.Background())
+	assert.False(t, ok)
+}
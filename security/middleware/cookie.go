@@ -0,0 +1,265 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/upnext-fng/fulcrum/security/jwt"
+)
+
+// CSRF protection modes for cookie authentication
+const (
+	// CSRFDoubleSubmit sets a random token in a script-readable cookie that
+	// must be echoed in the CSRF header
+	CSRFDoubleSubmit = "double_submit"
+	// CSRFSynchronizer derives the token from the session ID with CSRFSecret;
+	// the client receives it in the token response and sends it in the header.
+	// Tokens without a session ID are refused, since a token derived from the
+	// user alone would never change.
+	CSRFSynchronizer = "synchronizer"
+)
+
+var (
+	ErrInvalidCSRFToken    = errors.New("invalid csrf token")
+	ErrCookieAuthDisabled  = errors.New("cookie authentication is disabled")
+	ErrCSRFSessionRequired = errors.New("synchronizer csrf protection requires a session id")
+)
+
+// CookieConfig issues tokens as HttpOnly cookies. Enabling it always enables
+// CSRF protection for unsafe methods on cookie-authenticated requests.
+type CookieConfig struct {
+	Enabled          bool   `mapstructure:"enabled"`
+	AccessTokenName  string `mapstructure:"access_token_name"`
+	RefreshTokenName string `mapstructure:"refresh_token_name"`
+	Domain           string `mapstructure:"domain"`
+	Path             string `mapstructure:"path"`
+	// RefreshPath limits the refresh cookie to the refresh endpoint
+	RefreshPath string `mapstructure:"refresh_path"`
+	// SameSite is "lax", "strict" or "none"; defaults to lax
+	SameSite string `mapstructure:"same_site"`
+	// Insecure drops the Secure attribute, for local development over HTTP
+	Insecure bool `mapstructure:"insecure"`
+
+	CSRFMode       string `mapstructure:"csrf_mode"`
+	CSRFCookieName string `mapstructure:"csrf_cookie_name"`
+	CSRFHeaderName string `mapstructure:"csrf_header_name"`
+	CSRFSecret     string `mapstructure:"csrf_secret"`
+}
+
+func (c CookieConfig) withDefaults() CookieConfig {
+	if c.AccessTokenName == "" {
+		c.AccessTokenName = "access_token"
+	}
+	if c.RefreshTokenName == "" {
+		c.RefreshTokenName = "refresh_token"
+	}
+	if c.Path == "" {
+		c.Path = "/"
+	}
+	if c.RefreshPath == "" {
+		c.RefreshPath = c.Path
+	}
+	if c.CSRFMode == "" {
+		c.CSRFMode = CSRFDoubleSubmit
+	}
+	if c.CSRFCookieName == "" {
+		c.CSRFCookieName = "csrf_token"
+	}
+	if c.CSRFHeaderName == "" {
+		c.CSRFHeaderName = "X-CSRF-Token"
+	}
+	return c
+}
+
+func (c CookieConfig) validate() error {
+	switch c.CSRFMode {
+	case CSRFDoubleSubmit:
+	case CSRFSynchronizer:
+		if c.CSRFSecret == "" {
+			return fmt.Errorf("csrf_secret is required for %s csrf protection", CSRFSynchronizer)
+		}
+	default:
+		return fmt.Errorf("unknown csrf mode %q", c.CSRFMode)
+	}
+
+	switch strings.ToLower(c.SameSite) {
+	case "", "lax", "strict":
+	case "none":
+		if c.Insecure {
+			return fmt.Errorf("SameSite=None cookies must be secure")
+		}
+	default:
+		return fmt.Errorf("unknown same_site %q", c.SameSite)
+	}
+	return nil
+}
+
+func (c CookieConfig) sameSite() http.SameSite {
+	switch strings.ToLower(c.SameSite) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
+func (c CookieConfig) cookie(name, value, path string, expires time.Time, httpOnly bool) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Domain:   c.Domain,
+		Path:     path,
+		Secure:   !c.Insecure,
+		HttpOnly: httpOnly,
+		SameSite: c.sameSite(),
+	}
+	if expires.IsZero() {
+		cookie.MaxAge = -1
+		cookie.Expires = time.Unix(0, 0)
+	} else {
+		cookie.Expires = expires
+		cookie.MaxAge = int(time.Until(expires).Seconds())
+	}
+	return cookie
+}
+
+// SetTokenCookies stores the token pair in HttpOnly cookies and returns the
+// CSRF token the client must send with unsafe requests. claims are those the
+// pair was issued for.
+func (m *manager) SetTokenCookies(c echo.Context, pair *jwt.TokenPair, claims jwt.Claims) (string, error) {
+	if err := m.cookieError(); err != nil {
+		return "", err
+	}
+	config := m.cookies
+
+	if config.CSRFMode == CSRFSynchronizer && claims.SessionID == "" {
+		return "", ErrCSRFSessionRequired
+	}
+
+	c.SetCookie(config.cookie(config.AccessTokenName, pair.AccessToken.Token, config.Path, pair.AccessToken.ExpiresAt, true))
+	if pair.HasRefreshToken() {
+		c.SetCookie(config.cookie(config.RefreshTokenName, pair.RefreshToken.Token, config.RefreshPath, pair.RefreshToken.ExpiresAt, true))
+	}
+
+	if config.CSRFMode == CSRFSynchronizer {
+		return m.synchronizerToken(claims.SessionID), nil
+	}
+
+	// A refresh that keeps the refresh cookie keeps the CSRF cookie too, so
+	// that it does not expire with the access token while the refresh
+	// cookie still needs it
+	if !pair.HasRefreshToken() {
+		if existing := CookieExtractor(config.CSRFCookieName)(c); existing != "" {
+			return existing, nil
+		}
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	expiresAt := pair.AccessToken.ExpiresAt
+	if pair.HasRefreshToken() {
+		expiresAt = pair.RefreshToken.ExpiresAt
+	}
+	c.SetCookie(config.cookie(config.CSRFCookieName, token, config.Path, expiresAt, false))
+	return token, nil
+}
+
+// ClearTokenCookies expires the token and CSRF cookies
+func (m *manager) ClearTokenCookies(c echo.Context) {
+	if !m.config.Cookie.Enabled {
+		return
+	}
+	config := m.cookies
+
+	c.SetCookie(config.cookie(config.AccessTokenName, "", config.Path, time.Time{}, true))
+	c.SetCookie(config.cookie(config.RefreshTokenName, "", config.RefreshPath, time.Time{}, true))
+	c.SetCookie(config.cookie(config.CSRFCookieName, "", config.Path, time.Time{}, false))
+}
+
+// RefreshTokenFromCookie returns the refresh token cookie, if any
+func (m *manager) RefreshTokenFromCookie(c echo.Context) string {
+	if !m.config.Cookie.Enabled {
+		return ""
+	}
+	return CookieExtractor(m.cookies.RefreshTokenName)(c)
+}
+
+// VerifyCSRF checks the CSRF header of an unsafe request authenticated by a
+// cookie. Safe methods always pass.
+func (m *manager) VerifyCSRF(c echo.Context, claims *jwt.Claims) error {
+	if err := m.cookieError(); err != nil {
+		return err
+	}
+
+	switch c.Request().Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return nil
+	}
+
+	presented := c.Request().Header.Get(m.cookies.CSRFHeaderName)
+	if presented == "" {
+		return ErrInvalidCSRFToken
+	}
+
+	var expected string
+	if m.cookies.CSRFMode == CSRFSynchronizer {
+		if claims.SessionID == "" {
+			return ErrInvalidCSRFToken
+		}
+		expected = m.synchronizerToken(claims.SessionID)
+	} else {
+		expected = CookieExtractor(m.cookies.CSRFCookieName)(c)
+	}
+
+	if expected == "" || subtle.ConstantTimeCompare([]byte(presented), []byte(expected)) != 1 {
+		return ErrInvalidCSRFToken
+	}
+	return nil
+}
+
+func (m *manager) cookieError() error {
+	if !m.config.Cookie.Enabled {
+		return ErrCookieAuthDisabled
+	}
+	return m.cookieErr
+}
+
+// tokenFromCookie reports whether the request was authenticated by the
+// access token cookie, or a cookie of TokenLookup, rather than a header
+func (m *manager) tokenFromCookie(c echo.Context, tokenString string) bool {
+	for _, name := range m.cookieNames {
+		if CookieExtractor(name)(c) == tokenString {
+			return true
+		}
+	}
+	return false
+}
+
+// synchronizerToken binds a CSRF token to the session
+func (m *manager) synchronizerToken(sessionID string) string {
+	mac := hmac.New(sha256.New, []byte(m.cookies.CSRFSecret))
+	mac.Write([]byte("session:" + sessionID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/upnext-fng/fulcrum/security/jwt"
+)
+
+func newCookieServer(t *testing.T, cookie CookieConfig) (*echo.Echo, Service, jwt.Service) {
+	t.Helper()
+
+	config := Config{
+		JWTConfig: jwt.Config{Secret: "test-secret-key-123", AccessTokenTTL: time.Hour, RefreshTokenTTL: time.Hour * 24},
+		Cookie:    cookie,
+	}
+	jwtService := jwt.NewJWTService(config.JWTConfig)
+	service := NewService(config, jwtService)
+
+	e := echo.New()
+	handler := func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}
+	e.GET("/", handler, service.JWTMiddleware())
+	e.POST("/", handler, service.JWTMiddleware())
+	return e, service, jwtService
+}
+
+// login issues cookies the way the security facade does
+func login(t *testing.T, service Service, jwtService jwt.Service) ([]*http.Cookie, string) {
+	t.Helper()
+
+	claims := jwt.Claims{UserID: "test-user-123", SessionID: "session-1"}
+	pair, err := jwtService.GenerateTokenPair(claims, jwt.WithRefreshToken())
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/login", nil), rec)
+	csrfToken, err := service.SetTokenCookies(c, pair, claims)
+	require.NoError(t, err)
+	return rec.Result().Cookies(), csrfToken
+}
+
+func cookieRequest(e *echo.Echo, method string, cookies []*http.Cookie, csrfToken string) int {
+	req := httptest.NewRequest(method, "/", nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	if csrfToken != "" {
+		req.Header.Set("X-CSRF-Token", csrfToken)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestCookieAuth_DoubleSubmit(t *testing.T) {
+	e, service, jwtService := newCookieServer(t, CookieConfig{Enabled: true, SameSite: "strict", RefreshPath: "/auth/refresh"})
+	cookies, csrfToken := login(t, service, jwtService)
+
+	byName := make(map[string]*http.Cookie)
+	for _, cookie := range cookies {
+		byName[cookie.Name] = cookie
+	}
+	require.Contains(t, byName, "access_token")
+	assert.True(t, byName["access_token"].HttpOnly)
+	assert.True(t, byName["access_token"].Secure)
+	assert.Equal(t, http.SameSiteStrictMode, byName["access_token"].SameSite)
+	assert.Equal(t, "/auth/refresh", byName["refresh_token"].Path)
+	assert.False(t, byName["csrf_token"].HttpOnly)
+	assert.Equal(t, csrfToken, byName["csrf_token"].Value)
+
+	assert.Equal(t, http.StatusNoContent, cookieRequest(e, http.MethodGet, cookies, ""))
+	assert.Equal(t, http.StatusForbidden, cookieRequest(e, http.MethodPost, cookies, ""))
+	assert.Equal(t, http.StatusForbidden, cookieRequest(e, http.MethodPost, cookies, "forged"))
+	assert.Equal(t, http.StatusNoContent, cookieRequest(e, http.MethodPost, cookies, csrfToken))
+}
+
+func TestCookieAuth_Synchronizer(t *testing.T) {
+	e, service, jwtService := newCookieServer(t, CookieConfig{Enabled: true, CSRFMode: CSRFSynchronizer, CSRFSecret: "csrf-secret"})
+	cookies, csrfToken := login(t, service, jwtService)
+
+	for _, cookie := range cookies {
+		assert.NotEqual(t, "csrf_token", cookie.Name)
+	}
+
+	assert.Equal(t, http.StatusForbidden, cookieRequest(e, http.MethodPost, cookies, ""))
+	assert.Equal(t, http.StatusNoContent, cookieRequest(e, http.MethodPost, cookies, csrfToken))
+
+	// A token bound to another session is rejected
+	otherClaims := jwt.Claims{UserID: "test-user-123", SessionID: "session-2"}
+	pair, err := jwtService.GenerateTokenPair(otherClaims)
+	require.NoError(t, err)
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/", nil), httptest.NewRecorder())
+	otherToken, err := service.SetTokenCookies(c, pair, otherClaims)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, cookieRequest(e, http.MethodPost, cookies, otherToken))
+
+	// Tokens without a session would get a static per-user CSRF token
+	sessionless := jwt.Claims{UserID: "test-user-123"}
+	pair, err = jwtService.GenerateTokenPair(sessionless)
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	c = echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/", nil), rec)
+	_, err = service.SetTokenCookies(c, pair, sessionless)
+	assert.ErrorIs(t, err, ErrCSRFSessionRequired)
+	assert.Empty(t, rec.Result().Cookies())
+	assert.ErrorIs(t, service.VerifyCSRF(c, &sessionless), ErrInvalidCSRFToken)
+
+	// The synchronizer mode needs a secret
+	e, _, _ = newCookieServer(t, CookieConfig{Enabled: true, CSRFMode: CSRFSynchronizer})
+	assert.Equal(t, http.StatusInternalServerError, cookieRequest(e, http.MethodGet, cookies, ""))
+}
+
+func TestCookieAuth_TokenLookupCookies(t *testing.T) {
+	newServer := func(cookie CookieConfig) (*echo.Echo, jwt.Service) {
+		config := Config{
+			JWTConfig:   jwt.Config{Secret: "test-secret-key-123", AccessTokenTTL: time.Hour},
+			TokenLookup: "header:Authorization:Bearer,cookie:session_token",
+			Cookie:      cookie,
+		}
+		jwtService := jwt.NewJWTService(config.JWTConfig)
+		service := NewService(config, jwtService)
+
+		e := echo.New()
+		e.POST("/", func(c echo.Context) error {
+			return c.NoContent(http.StatusNoContent)
+		}, service.JWTMiddleware())
+		return e, jwtService
+	}
+
+	// Cookie sources are refused without cookie authentication
+	e, _ := newServer(CookieConfig{})
+	assert.Equal(t, http.StatusInternalServerError, cookieRequest(e, http.MethodPost, nil, ""))
+
+	// With it, tokens from a lookup cookie need a CSRF token as well
+	e, jwtService := newServer(CookieConfig{Enabled: true})
+	token, err := jwtService.GenerateAccessToken(jwt.Claims{UserID: "test-user-123"})
+	require.NoError(t, err)
+
+	cookies := []*http.Cookie{{Name: "session_token", Value: token.Token}}
+	assert.Equal(t, http.StatusForbidden, cookieRequest(e, http.MethodPost, cookies, ""))
+
+	cookies = append(cookies, &http.Cookie{Name: "csrf_token", Value: "csrf-value"})
+	assert.Equal(t, http.StatusNoContent, cookieRequest(e, http.MethodPost, cookies, "csrf-value"))
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// DefaultTokenLookup reads a bearer token from the Authorization header
+const DefaultTokenLookup = "header:Authorization:Bearer"
+
+// TokenExtractor returns the token carried by a request, or "" if absent
+type TokenExtractor func(c echo.Context) string
+
+// HeaderExtractor reads a token from header. With a scheme the header must
+// start with it, compared case-insensitively, e.g. "bearer  <token>".
+func HeaderExtractor(header, scheme string) TokenExtractor {
+	return func(c echo.Context) string {
+		value := strings.TrimSpace(c.Request().Header.Get(header))
+		if scheme == "" {
+			return value
+		}
+
+		fields := strings.Fields(value)
+		if len(fields) != 2 || !strings.EqualFold(fields[0], scheme) {
+			return ""
+		}
+		return fields[1]
+	}
+}
+
+// CookieExtractor reads a token from the named cookie
+func CookieExtractor(name string) TokenExtractor {
+	return func(c echo.Context) string {
+		cookie, err := c.Cookie(name)
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(cookie.Value)
+	}
+}
+
+// QueryExtractor reads a token from a query parameter, for clients such as
+// WebSocket and EventSource that cannot set headers
+func QueryExtractor(param string) TokenExtractor {
+	return func(c echo.Context) string {
+		return strings.TrimSpace(c.QueryParam(param))
+	}
+}
+
+// FormExtractor reads a token from a form field
+func FormExtractor(field string) TokenExtractor {
+	return func(c echo.Context) string {
+		return strings.TrimSpace(c.FormValue(field))
+	}
+}
+
+// ParseTokenLookup builds extractors from a comma separated list of
+// "<source>:<name>" entries tried in order, as in
+// "header:Authorization:Bearer,cookie:access_token,query:token,form:token".
+// Sources are header (with an optional scheme), cookie, query and form.
+func ParseTokenLookup(lookup string) ([]TokenExtractor, error) {
+	extractors, _, err := parseTokenLookup(lookup)
+	return extractors, err
+}
+
+// parseTokenLookup also returns the cookies read by lookup, which need CSRF
+// protection
+func parseTokenLookup(lookup string) ([]TokenExtractor, []string, error) {
+	var extractors []TokenExtractor
+	var cookies []string
+
+	for _, entry := range strings.Split(lookup, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) < 2 || parts[1] == "" {
+			return nil, nil, fmt.Errorf("invalid token lookup %q", entry)
+		}
+
+		source, name := strings.ToLower(parts[0]), parts[1]
+		if len(parts) == 3 && source != "header" {
+			return nil, nil, fmt.Errorf("invalid token lookup %q: only headers take a scheme", entry)
+		}
+
+		switch source {
+		case "header":
+			scheme := ""
+			if len(parts) == 3 {
+				scheme = strings.TrimSpace(parts[2])
+			}
+			extractors = append(extractors, HeaderExtractor(name, scheme))
+		case "cookie":
+			extractors = append(extractors, CookieExtractor(name))
+			cookies = append(cookies, name)
+		case "query":
+			extractors = append(extractors, QueryExtractor(name))
+		case "form":
+			extractors = append(extractors, FormExtractor(name))
+		default:
+			return nil, nil, fmt.Errorf("invalid token lookup %q: unknown source %q", entry, source)
+		}
+	}
+
+	if len(extractors) == 0 {
+		return nil, nil, fmt.Errorf("token lookup is empty")
+	}
+	return extractors, cookies, nil
+}
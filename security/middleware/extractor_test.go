@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/upnext-fng/fulcrum/security/jwt"
+)
+
+func TestParseTokenLookup(t *testing.T) {
+	extractors, err := ParseTokenLookup("header:Authorization:Bearer, cookie:access_token, query:token, form:access_token")
+	require.NoError(t, err)
+	m := &manager{extractors: extractors}
+
+	e := echo.New()
+	extract := func(req *http.Request) string {
+		return m.ExtractToken(e.NewContext(req, httptest.NewRecorder()))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderAuthorization, "bearer   header-token ")
+	assert.Equal(t, "header-token", extract(req))
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Basic dXNlcjpwYXNz")
+	req.AddCookie(&http.Cookie{Name: "access_token", Value: "cookie-token"})
+	assert.Equal(t, "cookie-token", extract(req))
+
+	req = httptest.NewRequest(http.MethodGet, "/events?token=query-token", nil)
+	assert.Equal(t, "query-token", extract(req))
+
+	form := url.Values{"access_token": {"form-token"}}
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	assert.Equal(t, "form-token", extract(req))
+
+	assert.Empty(t, extract(httptest.NewRequest(http.MethodGet, "/", nil)))
+
+	for _, lookup := range []string{"", "header", "body:token", "query:token:Bearer"} {
+		_, err := ParseTokenLookup(lookup)
+		assert.Error(t, err, lookup)
+	}
+}
+
+func TestJWTMiddleware_TokenLookup(t *testing.T) {
+	e, jwtService := newTestServer(t, Config{TokenLookup: "query:token"})
+
+	token, err := jwtService.GenerateAccessToken(jwt.Claims{UserID: "test-user-123"})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?token="+token.Token, nil))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	// The header is no longer consulted
+	assert.Equal(t, http.StatusUnauthorized, serve(e, token.Token).Code)
+
+	e, _ = newTestServer(t, Config{TokenLookup: "body:token"})
+	assert.Equal(t, http.StatusInternalServerError, serve(e, token.Token).Code)
+}
@@ -1,8 +1,10 @@
 package middleware
 
 import (
-	"github.com/golang-jwt/jwt/v5"
+	jwtlib "github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
+	httpmiddleware "github.com/upnext-fng/fulcrum/http/middleware"
+	"github.com/upnext-fng/fulcrum/security/jwt"
 )
 
 type Service interface {
@@ -11,5 +13,20 @@ type Service interface {
 	CORSMiddleware() echo.MiddlewareFunc
 	RateLimitMiddleware() echo.MiddlewareFunc
 	ExtractToken(echo.Context) string
-	ValidateToken(tokenString string) (*jwt.Token, error)
+	ValidateToken(tokenString string) (*jwtlib.Token, error)
+
+	// Cookie authentication, see CookieConfig
+	SetTokenCookies(c echo.Context, pair *jwt.TokenPair, claims jwt.Claims) (string, error)
+	ClearTokenCookies(c echo.Context)
+	RefreshTokenFromCookie(c echo.Context) string
+	VerifyCSRF(c echo.Context, claims *jwt.Claims) error
+}
+
+// RateLimitStore keeps rate limit state, shared by all instances when the
+// store is external
+type RateLimitStore = httpmiddleware.RateLimitStore
+
+// SessionValidator checks the server-side session a token was issued for
+type SessionValidator interface {
+	ValidateSession(sessionID string, ipAddress string) error
 }
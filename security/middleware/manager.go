@@ -1,11 +1,12 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
-	"strings"
 
 	jwtlib "github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
+	httpmiddleware "github.com/upnext-fng/fulcrum/http/middleware"
 	"github.com/upnext-fng/fulcrum/security/jwt"
 )
 
@@ -13,17 +14,54 @@ type manager struct {
 	config       Config
 	jwtService   jwt.Service
 	claimsParser jwt.ClaimsParser
+	extractors   []TokenExtractor
+	lookupErr    error
+	cookies      CookieConfig
+	cookieErr    error
+	cookieNames  []string
+	rateLimiter  *rateLimiter
+	cors         echo.MiddlewareFunc
+	corsErr      error
 }
 
 func NewManager(config Config, jwtService jwt.Service) Service {
 	// Create claims parser using JWT config from middleware config
 	claimsParser := jwt.NewClaimsParser(config.JWTConfig)
 
-	return &manager{
+	m := &manager{
 		config:       config,
 		jwtService:   jwtService,
 		claimsParser: claimsParser,
+		extractors:   config.TokenExtractors,
 	}
+
+	// Lookup errors are reported by JWTMiddleware so the signature stays
+	var lookupCookies []string
+	if len(m.extractors) == 0 {
+		lookup := config.TokenLookup
+		if lookup == "" {
+			lookup = DefaultTokenLookup
+		}
+		m.extractors, lookupCookies, m.lookupErr = parseTokenLookup(lookup)
+	}
+
+	m.cors, m.corsErr = httpmiddleware.NewCORS(config.CORS)
+
+	if config.RateLimit.Enabled {
+		m.rateLimiter = newRateLimiter(config.RateLimit)
+	}
+
+	if config.Cookie.Enabled {
+		m.cookies = config.Cookie.withDefaults()
+		m.cookieErr = m.cookies.validate()
+		m.extractors = append(m.extractors, CookieExtractor(m.cookies.AccessTokenName))
+		m.cookieNames = append(lookupCookies, m.cookies.AccessTokenName)
+	} else if len(lookupCookies) > 0 {
+		// Cookies would authenticate cross-site requests without CSRF checks
+		m.lookupErr = fmt.Errorf("%w: token lookup reads cookie %q without csrf protection", ErrCookieAuthDisabled, lookupCookies[0])
+	}
+
+	return m
 }
 
 func (m *manager) JWTMiddleware() echo.MiddlewareFunc {
@@ -33,84 +71,102 @@ func (m *manager) JWTMiddleware() echo.MiddlewareFunc {
 				return next(c)
 			}
 
+			if m.lookupErr != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "invalid token lookup").SetInternal(m.lookupErr)
+			}
+			if m.cookieErr != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "invalid cookie configuration").SetInternal(m.cookieErr)
+			}
+
 			tokenString := m.ExtractToken(c)
 			if tokenString == "" {
-				return m.createUnauthorizedError("missing authorization token")
+				return m.bearerChallenge(c, http.StatusUnauthorized, "", AuthError{Message: "missing authorization token"}, nil)
 			}
 
-			token, err := m.jwtService.ValidateToken(tokenString)
+			token, err := m.jwtService.ValidateToken(tokenString, m.validationOptions()...)
 			if err != nil {
-				return m.createUnauthorizedError("invalid or expired token")
+				return m.rejectToken(c, err)
 			}
 
 			if !token.IsValid {
-				return m.createUnauthorizedError("invalid token")
+				return m.rejectToken(c, jwt.ErrInvalidToken)
 			}
 
 			claims, err := m.claimsParser.ParseClaims(token.Token)
 			if err != nil {
-				return m.createUnauthorizedError("invalid token claims")
+				return m.rejectToken(c, err)
 			}
 
-			c.Set("user_id", claims.UserID)
-			c.Set("token", tokenString)
-			c.Set("claims", claims)
+			// Browsers attach cookies to cross-site requests, headers they do not
+			if m.tokenFromCookie(c, tokenString) {
+				if err := m.VerifyCSRF(c, claims); err != nil {
+					return echo.NewHTTPError(http.StatusForbidden, AuthError{Message: err.Error(), Error: "csrf_invalid"})
+				}
+			}
+
+			if m.config.SessionValidator != nil && claims.SessionID != "" {
+				if err := m.config.SessionValidator.ValidateSession(claims.SessionID, c.RealIP()); err != nil {
+					return m.bearerChallenge(c, http.StatusUnauthorized, BearerInvalidToken, AuthError{Message: "session has ended"}, nil)
+				}
+			}
 
-			return next(c)
+			return next(SetPrincipal(c, claims, tokenString))
 		}
 	}
 }
 
+func (m *manager) validationOptions() []jwt.ValidationOption {
+	var opts []jwt.ValidationOption
+	if m.config.RequiredIssuer != "" {
+		opts = append(opts, jwt.WithRequiredIssuer(m.config.RequiredIssuer))
+	}
+	if m.config.RequiredAudience != "" {
+		opts = append(opts, jwt.WithRequiredAudience(m.config.RequiredAudience))
+	}
+	if len(m.config.RequiredScopes) > 0 {
+		opts = append(opts, jwt.WithRequiredScopes(m.config.RequiredScopes...))
+	}
+	return opts
+}
+
 func (m *manager) AuthMiddleware() echo.MiddlewareFunc {
 	return m.JWTMiddleware()
 }
 
 func (m *manager) CORSMiddleware() echo.MiddlewareFunc {
-	return func(next echo.HandlerFunc) echo.HandlerFunc {
-		return func(c echo.Context) error {
-			c.Response().Header().Set("Access-Control-Allow-Origin", "*")
-			c.Response().Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			c.Response().Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization")
-
-			if c.Request().Method == "OPTIONS" {
-				return c.NoContent(http.StatusOK)
+	if m.corsErr != nil {
+		return func(next echo.HandlerFunc) echo.HandlerFunc {
+			return func(c echo.Context) error {
+				return echo.NewHTTPError(http.StatusInternalServerError, "invalid cors configuration").SetInternal(m.corsErr)
 			}
-
-			return next(c)
 		}
 	}
+	return m.cors
 }
 
 func (m *manager) RateLimitMiddleware() echo.MiddlewareFunc {
-	return func(next echo.HandlerFunc) echo.HandlerFunc {
-		return func(c echo.Context) error {
-			return next(c)
+	if m.rateLimiter == nil {
+		return func(next echo.HandlerFunc) echo.HandlerFunc {
+			return next
 		}
 	}
+	return m.rateLimiter.middleware
 }
 
+// ExtractToken returns the first token found by the configured extractors
 func (m *manager) ExtractToken(c echo.Context) string {
-	auth := c.Request().Header.Get("Authorization")
-	if auth == "" {
-		return ""
-	}
-
-	parts := strings.Split(auth, " ")
-	if len(parts) != 2 || parts[0] != "Bearer" {
-		return ""
+	for _, extract := range m.extractors {
+		if token := extract(c); token != "" {
+			return token
+		}
 	}
-
-	return parts[1]
+	return ""
 }
 
 func (m *manager) ValidateToken(tokenString string) (*jwtlib.Token, error) {
-	validatedToken, err := m.jwtService.ValidateToken(tokenString)
+	validatedToken, err := m.jwtService.ValidateToken(tokenString, m.validationOptions()...)
 	if err != nil {
 		return nil, err
 	}
 	return validatedToken.Token, nil
 }
-
-func (m *manager) createUnauthorizedError(message string) error {
-	return echo.NewHTTPError(http.StatusUnauthorized, message)
-}
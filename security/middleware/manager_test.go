@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/upnext-fng/fulcrum/security/jwt"
+)
+
+func newTestServer(t *testing.T, config Config) (*echo.Echo, jwt.Service) {
+	t.Helper()
+
+	config.JWTConfig = jwt.Config{
+		Secret:         "test-secret-key-123",
+		AccessTokenTTL: time.Hour,
+		Issuer:         "auth.example.com",
+	}
+	jwtService := jwt.NewJWTService(config.JWTConfig)
+
+	e := echo.New()
+	e.Use(NewService(config, jwtService).JWTMiddleware())
+	e.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+	return e, jwtService
+}
+
+func serve(e *echo.Echo, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if token != "" {
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestJWTMiddleware_BearerChallenges(t *testing.T) {
+	e, jwtService := newTestServer(t, Config{Realm: "api", RequiredScopes: []string{"read", "write"}})
+
+	rec := serve(e, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, `Bearer realm="api"`, rec.Header().Get(echo.HeaderWWWAuthenticate))
+
+	expired, err := jwtService.GenerateAccessToken(
+		jwt.Claims{UserID: "test-user-123", Scopes: []string{"read", "write"}},
+		jwt.WithIssuedAt(time.Now().Add(-2*time.Hour)),
+		jwt.WithExpiresAt(time.Now().Add(-time.Hour)),
+	)
+	require.NoError(t, err)
+	rec = serve(e, expired.Token)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Header().Get(echo.HeaderWWWAuthenticate), `error="invalid_token"`)
+	assert.Contains(t, rec.Body.String(), `"code":"token_expired"`)
+
+	limited, err := jwtService.GenerateAccessToken(jwt.Claims{UserID: "test-user-123", Scopes: []string{"read"}})
+	require.NoError(t, err)
+	rec = serve(e, limited.Token)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Header().Get(echo.HeaderWWWAuthenticate), `error="insufficient_scope"`)
+	assert.Contains(t, rec.Header().Get(echo.HeaderWWWAuthenticate), `scope="read write"`)
+	assert.Contains(t, rec.Body.String(), `"missing_scopes":["write"]`)
+
+	allowed, err := jwtService.GenerateAccessToken(jwt.Claims{UserID: "test-user-123", Scopes: []string{"read", "write"}})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, serve(e, allowed.Token).Code)
+}
@@ -0,0 +1,186 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Identities a rate limit can be keyed by
+const (
+	RateLimitByUser   = "user"
+	RateLimitByClient = "client"
+	RateLimitByAPIKey = "api_key"
+	RateLimitByIP     = "ip"
+)
+
+// apiKeyTokenType marks claims set by the apikey middleware, see
+// apikey.TokenType
+const apiKeyTokenType = "api_key"
+
+// RateLimitConfig limits requests per caller. The caller is identified by the
+// first identity in KeyBy the request has; user, client and API key
+// identities need the middleware to run after authentication. Requests
+// without an authenticated API key are limited by IP under api_key.
+type RateLimitConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	Rate    float64       `mapstructure:"rate"`
+	Burst   int           `mapstructure:"burst"`
+	Period  time.Duration `mapstructure:"period"`
+
+	// KeyBy defaults to user, client, ip
+	KeyBy []string `mapstructure:"key_by"`
+
+	// Routes override the default limit for matching routes. Scopes grant
+	// the most generous matching tier to tokens holding the scope.
+	Routes []RateLimitTier `mapstructure:"routes"`
+	Scopes []RateLimitTier `mapstructure:"scopes"`
+
+	// Store defaults to an in-memory store, which is per instance
+	Store RateLimitStore `mapstructure:"-"`
+}
+
+// RateLimitTier is a named limit applied to a route or a scope. Path is the
+// echo route path, e.g. "/users/:id"; an empty Method matches any method.
+type RateLimitTier struct {
+	Name   string        `mapstructure:"name"`
+	Method string        `mapstructure:"method"`
+	Path   string        `mapstructure:"path"`
+	Scope  string        `mapstructure:"scope"`
+	Rate   float64       `mapstructure:"rate"`
+	Burst  int           `mapstructure:"burst"`
+	Period time.Duration `mapstructure:"period"`
+}
+
+func (t RateLimitTier) limit() Limit {
+	return Limit{Rate: t.Rate, Burst: t.Burst, Period: t.Period}
+}
+
+type rateLimiter struct {
+	config RateLimitConfig
+	store  RateLimitStore
+	now    func() time.Time
+}
+
+func newRateLimiter(config RateLimitConfig) *rateLimiter {
+	if len(config.KeyBy) == 0 {
+		config.KeyBy = []string{RateLimitByUser, RateLimitByClient, RateLimitByIP}
+	}
+
+	store := config.Store
+	if store == nil {
+		store = NewMemoryRateLimitStore()
+	}
+
+	return &rateLimiter{
+		config: config,
+		store:  store,
+		now:    time.Now,
+	}
+}
+
+func (r *rateLimiter) middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		identity := r.identity(c)
+		if identity == "" {
+			return next(c)
+		}
+
+		tier, limit := r.tier(c)
+		result, err := r.store.Allow(tier+":"+identity, limit, r.now())
+		if err != nil {
+			// Fail open, an unavailable store must not take the API down
+			c.Logger().Errorf("rate limit store: %v", err)
+			return next(c)
+		}
+
+		header := c.Response().Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+
+		if !result.Allowed {
+			header.Set(echo.HeaderRetryAfter, strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			return echo.NewHTTPError(http.StatusTooManyRequests, "rate limit exceeded")
+		}
+		return next(c)
+	}
+}
+
+// identity returns the rate limit key of the caller, e.g. "user:42"
+func (r *rateLimiter) identity(c echo.Context) string {
+	claims, _ := ClaimsFrom(c)
+
+	for _, keyBy := range r.config.KeyBy {
+		switch keyBy {
+		case RateLimitByUser:
+			if claims != nil && claims.UserID != "" {
+				return "user:" + claims.UserID
+			}
+		case RateLimitByClient:
+			if claims != nil && claims.ClientID != "" {
+				return "client:" + claims.ClientID
+			}
+		case RateLimitByAPIKey:
+			// Only authenticated keys count, unverified headers could be
+			// rotated to get a fresh budget on every request
+			if claims != nil && claims.TokenType == apiKeyTokenType && claims.ID != "" {
+				return "api_key:" + claims.ID
+			}
+			if ip := c.RealIP(); ip != "" {
+				return "ip:" + ip
+			}
+		case RateLimitByIP:
+			if ip := c.RealIP(); ip != "" {
+				return "ip:" + ip
+			}
+		}
+	}
+	return ""
+}
+
+// tier picks the limit for the request: a matching route first, then the
+// most generous scope tier, then the default
+func (r *rateLimiter) tier(c echo.Context) (string, Limit) {
+	for i, route := range r.config.Routes {
+		if route.Path != c.Path() {
+			continue
+		}
+		if route.Method != "" && route.Method != c.Request().Method {
+			continue
+		}
+		return tierName("route", i, route), route.limit()
+	}
+
+	if claims, ok := ClaimsFrom(c); ok {
+		best := -1
+		for i, tier := range r.config.Scopes {
+			if !claims.HasScope(tier.Scope) {
+				continue
+			}
+			if best < 0 || tier.limit().Interval() < r.config.Scopes[best].limit().Interval() {
+				best = i
+			}
+		}
+		if best >= 0 {
+			return tierName("scope", best, r.config.Scopes[best]), r.config.Scopes[best].limit()
+		}
+	}
+
+	return "default", Limit{Rate: r.config.Rate, Burst: r.config.Burst, Period: r.config.Period}
+}
+
+func tierName(kind string, index int, tier RateLimitTier) string {
+	if tier.Name != "" {
+		return kind + ":" + tier.Name
+	}
+	return fmt.Sprintf("%s:%d", kind, index)
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
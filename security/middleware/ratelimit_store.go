@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"time"
+
+	echomiddleware "github.com/labstack/echo/v4/middleware"
+	httpmiddleware "github.com/upnext-fng/fulcrum/http/middleware"
+)
+
+const rateLimitSweepInterval = time.Minute
+
+// The GCRA primitives live in http/middleware, which cannot import this
+// package; they are re-exported here so both share one implementation.
+type (
+	// Limit allows Rate requests per Period with bursts of up to Burst requests
+	Limit = httpmiddleware.Limit
+	// RateLimitResult is the outcome of a rate limit check
+	RateLimitResult = httpmiddleware.RateLimitResult
+	// EchoRateLimiterStore adapts a RateLimitStore to echo's RateLimiter
+	// middleware, applying the same limit to every identifier
+	EchoRateLimiterStore = httpmiddleware.EchoRateLimiterStore
+)
+
+// NewEchoRateLimiterStore creates an echo store, e.g. for
+// echomiddleware.RateLimiter(NewEchoRateLimiterStore(gormStore, limit))
+func NewEchoRateLimiterStore(store RateLimitStore, limit Limit) echomiddleware.RateLimiterStore {
+	return httpmiddleware.NewEchoRateLimiterStore(store, limit)
+}
+
+// NewMemoryRateLimitStore creates a process-local GCRA store. Keys whose
+// bucket has refilled are evicted, so idle clients use no memory.
+func NewMemoryRateLimitStore() RateLimitStore {
+	return httpmiddleware.NewMemoryRateLimitStore()
+}
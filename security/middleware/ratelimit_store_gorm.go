@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+
+	"github.com/upnext-fng/fulcrum/database"
+	httpmiddleware "github.com/upnext-fng/fulcrum/http/middleware"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormRateLimitStore struct {
+	db database.DatabaseService
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// NewGormRateLimitStore keeps GCRA state in the rate_limit_buckets table so
+// limits hold across instances. Each check locks the caller's row, so
+// concurrent requests are serialized per key. Instances should keep their
+// clocks in sync. Migrate RateLimitBucket before use.
+func NewGormRateLimitStore(db database.DatabaseService) RateLimitStore {
+	return &gormRateLimitStore{
+		db: db,
+	}
+}
+
+func (s *gormRateLimitStore) Allow(key string, limit Limit, now time.Time) (RateLimitResult, error) {
+	var result RateLimitResult
+
+	err := s.db.Connection().Transaction(func(tx *gorm.DB) error {
+		// Create the row first so there is always something to lock
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&RateLimitBucket{Key: key, TAT: now, UpdatedAt: now}).Error
+		if err != nil {
+			return err
+		}
+
+		var bucket RateLimitBucket
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("key = ?", key).
+			First(&bucket).Error
+		if err != nil {
+			return err
+		}
+
+		var tat time.Time
+		tat, result = httpmiddleware.GCRA(bucket.TAT, limit, now)
+		if !result.Allowed {
+			return nil
+		}
+
+		return tx.Model(&RateLimitBucket{}).
+			Where("key = ?", key).
+			Updates(map[string]interface{}{"tat": tat, "updated_at": now}).Error
+	})
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	s.sweep(now)
+	return result, nil
+}
+
+// sweep deletes full buckets, which behave like missing ones
+func (s *gormRateLimitStore) sweep(now time.Time) {
+	s.mu.Lock()
+	if now.Sub(s.lastSweep) < rateLimitSweepInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = now
+	s.mu.Unlock()
+
+	// Best effort, the next sweep retries
+	s.db.Connection().Where("tat < ?", now).Delete(&RateLimitBucket{})
+}
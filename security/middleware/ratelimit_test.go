@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	echomiddleware "github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/upnext-fng/fulcrum/security/jwt"
+)
+
+func newRateLimitServer(config RateLimitConfig, now *time.Time) *echo.Echo {
+	limiter := newRateLimiter(config)
+	limiter.now = func() time.Time { return *now }
+
+	authenticate := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if userID := c.Request().Header.Get("X-Test-User"); userID != "" {
+				claims := &jwt.Claims{UserID: userID, Scopes: []string{c.Request().Header.Get("X-Test-Scope")}}
+				return next(SetPrincipal(c, claims, ""))
+			}
+			if keyID := c.Request().Header.Get("X-Test-API-Key-ID"); keyID != "" {
+				claims := &jwt.Claims{ID: keyID, UserID: "owner", TokenType: apiKeyTokenType}
+				return next(SetPrincipal(c, claims, ""))
+			}
+			return next(c)
+		}
+	}
+
+	e := echo.New()
+	handler := func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}
+	e.GET("/", handler, authenticate, limiter.middleware)
+	e.POST("/exports", handler, authenticate, limiter.middleware)
+	return e
+}
+
+func limitedRequest(e *echo.Echo, method, path, userID, scope string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("X-Test-User", userID)
+	req.Header.Set("X-Test-Scope", scope)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRateLimit_PerUser(t *testing.T) {
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	e := newRateLimitServer(RateLimitConfig{Rate: 2, Burst: 2}, &now)
+
+	rec := limitedRequest(e, http.MethodGet, "/", "alice", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+
+	assert.Equal(t, http.StatusNoContent, limitedRequest(e, http.MethodGet, "/", "alice", "").Code)
+
+	rec = limitedRequest(e, http.MethodGet, "/", "alice", "")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "1", rec.Header().Get(echo.HeaderRetryAfter))
+
+	// Other callers have their own budget
+	assert.Equal(t, http.StatusNoContent, limitedRequest(e, http.MethodGet, "/", "bob", "").Code)
+	assert.Equal(t, http.StatusNoContent, limitedRequest(e, http.MethodGet, "/", "", "").Code)
+
+	now = now.Add(500 * time.Millisecond)
+	assert.Equal(t, http.StatusNoContent, limitedRequest(e, http.MethodGet, "/", "alice", "").Code)
+}
+
+func TestRateLimit_Tiers(t *testing.T) {
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	e := newRateLimitServer(RateLimitConfig{
+		Rate:   1,
+		Routes: []RateLimitTier{{Name: "exports", Method: http.MethodPost, Path: "/exports", Rate: 1, Period: time.Hour}},
+		Scopes: []RateLimitTier{{Name: "partner", Scope: "partner", Rate: 100}},
+	}, &now)
+
+	for i := 0; i < 50; i++ {
+		assert.Equal(t, http.StatusNoContent, limitedRequest(e, http.MethodGet, "/", "partner-user", "partner").Code)
+	}
+	assert.Equal(t, http.StatusNoContent, limitedRequest(e, http.MethodGet, "/", "alice", "").Code)
+	assert.Equal(t, http.StatusTooManyRequests, limitedRequest(e, http.MethodGet, "/", "alice", "").Code)
+
+	// The route tier applies to everyone, including partners
+	assert.Equal(t, http.StatusNoContent, limitedRequest(e, http.MethodPost, "/exports", "partner-user", "partner").Code)
+	rec := limitedRequest(e, http.MethodPost, "/exports", "partner-user", "partner")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "3600", rec.Header().Get(echo.HeaderRetryAfter))
+}
+
+func TestRateLimit_PerAPIKey(t *testing.T) {
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	e := newRateLimitServer(RateLimitConfig{Rate: 1, KeyBy: []string{RateLimitByAPIKey}}, &now)
+
+	request := func(keyID, header string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Test-API-Key-ID", keyID)
+		req.Header.Set("X-API-Key", header)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Authenticated keys are limited by key ID, whatever the header holds
+	assert.Equal(t, http.StatusNoContent, request("key-1", "a"))
+	assert.Equal(t, http.StatusTooManyRequests, request("key-1", "b"))
+	assert.Equal(t, http.StatusNoContent, request("key-2", "a"))
+
+	// Rotating unauthenticated keys does not escape the IP limit
+	assert.Equal(t, http.StatusNoContent, request("", "c"))
+	assert.Equal(t, http.StatusTooManyRequests, request("", "d"))
+}
+
+func TestEchoRateLimiterStore(t *testing.T) {
+	e := echo.New()
+	e.Use(echomiddleware.RateLimiter(NewEchoRateLimiterStore(NewMemoryRateLimitStore(), Limit{Rate: 1, Period: time.Minute})))
+	e.POST("/login", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	login := func() int {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/login", nil))
+		return rec.Code
+	}
+	assert.Equal(t, http.StatusNoContent, login())
+	assert.Equal(t, http.StatusTooManyRequests, login())
+}
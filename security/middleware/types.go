@@ -1,15 +1,32 @@
 package middleware
 
 import (
+	"time"
+
 	"github.com/labstack/echo/v4"
+	"github.com/upnext-fng/fulcrum/security/jwt"
 )
 
+// AuthContext is the context JWTMiddleware passes to the next handler
 type AuthContext struct {
 	UserID string
 	Token  string
+	Claims *jwt.Claims
 	echo.Context
 }
 
 type Func func(echo.HandlerFunc) echo.HandlerFunc
 
 type ErrorHandler func(echo.Context, error) error
+
+// RateLimitBucket is the GCRA state of one rate limit key
+type RateLimitBucket struct {
+	Key string `gorm:"primaryKey;size:255"`
+	// TAT is the theoretical arrival time of the next request
+	TAT       time.Time `gorm:"column:tat;not null;index"`
+	UpdatedAt time.Time `gorm:"not null"`
+}
+
+func (RateLimitBucket) TableName() string {
+	return "rate_limit_buckets"
+}
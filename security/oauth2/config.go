@@ -0,0 +1,13 @@
+package oauth2
+
+import "time"
+
+type Config struct {
+	// BasePath prefixes the token, introspect and revoke endpoints
+	BasePath string `mapstructure:"base_path"`
+	// AuthorizationCodeTTL bounds how long a code can be exchanged
+	AuthorizationCodeTTL time.Duration `mapstructure:"authorization_code_ttl"`
+	// AllowPlainPKCE accepts the "plain" code challenge method. S256 is
+	// always accepted.
+	AllowPlainPKCE bool `mapstructure:"allow_plain_pkce"`
+}
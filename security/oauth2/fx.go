@@ -0,0 +1,5 @@
+package oauth2
+
+import "go.uber.org/fx"
+
+var Module = fx.Provide(NewService)
@@ -0,0 +1,110 @@
+package oauth2
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (m *manager) RegisterRoutes(e *echo.Echo) {
+	e.POST(m.config.BasePath+"/token", m.TokenHandler)
+	e.POST(m.config.BasePath+"/introspect", m.IntrospectHandler)
+	e.POST(m.config.BasePath+"/revoke", m.RevokeHandler)
+}
+
+// TokenHandler implements the RFC 6749 token endpoint
+func (m *manager) TokenHandler(c echo.Context) error {
+	client, err := m.clientFromRequest(c)
+	if err != nil {
+		return m.writeError(c, err)
+	}
+
+	var response *TokenResponse
+	switch grantType := c.FormValue("grant_type"); grantType {
+	case GrantClientCredentials:
+		response, err = m.clientCredentialsGrant(client, c.FormValue("scope"))
+	case GrantAuthorizationCode:
+		response, err = m.authorizationCodeGrant(client, c.FormValue("code"), c.FormValue("redirect_uri"), c.FormValue("code_verifier"))
+	case GrantRefreshToken:
+		response, err = m.refreshTokenGrant(client, c.FormValue("refresh_token"), c.FormValue("scope"))
+	case "":
+		err = ErrInvalidRequest.WithDescription("grant_type is required")
+	default:
+		err = ErrUnsupportedGrantType
+	}
+	if err != nil {
+		return m.writeError(c, err)
+	}
+
+	noStore(c)
+	return c.JSON(http.StatusOK, response)
+}
+
+// IntrospectHandler implements RFC 7662. Only confidential clients, such as
+// resource servers, may introspect; public clients cannot prove who they are.
+func (m *manager) IntrospectHandler(c echo.Context) error {
+	client, err := m.clientFromRequest(c)
+	if err != nil {
+		return m.writeError(c, err)
+	}
+	if client.Public {
+		return m.writeError(c, ErrInvalidClient.WithDescription("introspection requires client authentication"))
+	}
+
+	token := c.FormValue("token")
+	if token == "" {
+		return m.writeError(c, ErrInvalidRequest.WithDescription("token is required"))
+	}
+
+	noStore(c)
+	return c.JSON(http.StatusOK, m.introspect(token))
+}
+
+// RevokeHandler implements RFC 7009
+func (m *manager) RevokeHandler(c echo.Context) error {
+	client, err := m.clientFromRequest(c)
+	if err != nil {
+		return m.writeError(c, err)
+	}
+
+	token := c.FormValue("token")
+	if token == "" {
+		return m.writeError(c, ErrInvalidRequest.WithDescription("token is required"))
+	}
+
+	if err := m.revoke(client, token); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+// clientFromRequest authenticates the client with HTTP Basic credentials or
+// client_id and client_secret form parameters
+func (m *manager) clientFromRequest(c echo.Context) (*Client, error) {
+	clientID, secret, ok := c.Request().BasicAuth()
+	if !ok {
+		clientID = c.FormValue("client_id")
+		secret = c.FormValue("client_secret")
+	}
+	return m.authenticateClient(clientID, secret)
+}
+
+func (m *manager) writeError(c echo.Context, err error) error {
+	var oauthErr *Error
+	if !errors.As(err, &oauthErr) {
+		return err
+	}
+
+	if errors.Is(oauthErr, ErrInvalidClient) {
+		c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Basic realm="oauth"`)
+	}
+
+	noStore(c)
+	return c.JSON(oauthErr.Status, oauthErr)
+}
+
+func noStore(c echo.Context) {
+	c.Response().Header().Set("Cache-Control", "no-store")
+	c.Response().Header().Set("Pragma", "no-cache")
+}
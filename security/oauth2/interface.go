@@ -0,0 +1,38 @@
+package oauth2
+
+import (
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Service interface {
+	RegisterClient(request RegisterClientRequest) (*RegisteredClient, error)
+	DeleteClient(clientID string) error
+
+	// IssueAuthorizationCode is called by the application's authorization
+	// endpoint once the user has signed in and approved the request. The
+	// caller redirects to RedirectURI with the returned code.
+	IssueAuthorizationCode(request AuthorizationRequest) (string, error)
+
+	// RegisterRoutes mounts the token, introspect and revoke endpoints
+	RegisterRoutes(e *echo.Echo)
+	TokenHandler(c echo.Context) error
+	IntrospectHandler(c echo.Context) error
+	RevokeHandler(c echo.Context) error
+}
+
+type ClientStore interface {
+	Create(client *Client) error
+	Find(clientID string) (*Client, error)
+	Delete(clientID string) error
+}
+
+// CodeStore persists authorization codes. Consume must be atomic and return
+// ErrCodeUsed when the code was already exchanged. It only consumes a code
+// issued to clientID for redirectURI and otherwise returns ErrCodeNotFound,
+// so other clients cannot burn it.
+type CodeStore interface {
+	Save(code *AuthorizationCode) error
+	Consume(hash, clientID, redirectURI string, usedAt time.Time) (*AuthorizationCode, error)
+}
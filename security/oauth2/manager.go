@@ -0,0 +1,399 @@
+package oauth2
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/upnext-fng/fulcrum/security/jwt"
+)
+
+const (
+	defaultBasePath             = "/oauth"
+	defaultAuthorizationCodeTTL = 5 * time.Minute
+)
+
+type manager struct {
+	config  Config
+	clients ClientStore
+	codes   CodeStore
+	jwt     jwt.Service
+}
+
+func NewManager(config Config, clients ClientStore, codes CodeStore, jwtService jwt.Service) Service {
+	if config.BasePath == "" {
+		config.BasePath = defaultBasePath
+	}
+	config.BasePath = strings.TrimSuffix(config.BasePath, "/")
+	if config.AuthorizationCodeTTL <= 0 {
+		config.AuthorizationCodeTTL = defaultAuthorizationCodeTTL
+	}
+
+	return &manager{
+		config:  config,
+		clients: clients,
+		codes:   codes,
+		jwt:     jwtService,
+	}
+}
+
+func (m *manager) RegisterClient(request RegisterClientRequest) (*RegisteredClient, error) {
+	grantTypes := request.GrantTypes
+	if len(grantTypes) == 0 {
+		grantTypes = []string{GrantAuthorizationCode, GrantRefreshToken}
+	}
+	for _, grantType := range grantTypes {
+		switch grantType {
+		case GrantAuthorizationCode, GrantRefreshToken:
+		case GrantClientCredentials:
+			if request.Public {
+				return nil, ErrInvalidRequest.WithDescription("public clients cannot use client_credentials")
+			}
+		default:
+			return nil, ErrUnsupportedGrantType.WithDescription(grantType)
+		}
+	}
+	if contains(grantTypes, GrantAuthorizationCode) && len(request.RedirectURIs) == 0 {
+		return nil, ErrInvalidRequest.WithDescription("authorization_code requires a redirect URI")
+	}
+
+	client := &Client{
+		ID:           randomString(16),
+		Name:         request.Name,
+		RedirectURIs: request.RedirectURIs,
+		GrantTypes:   grantTypes,
+		Scopes:       request.Scopes,
+		Public:       request.Public,
+		CreatedAt:    time.Now(),
+	}
+
+	var secret string
+	if !client.Public {
+		secret = randomString(32)
+		client.SecretHash = hashValue(secret)
+	}
+
+	if err := m.clients.Create(client); err != nil {
+		return nil, err
+	}
+
+	return &RegisteredClient{
+		ClientID:     client.ID,
+		ClientSecret: secret,
+		Client:       client,
+	}, nil
+}
+
+func (m *manager) DeleteClient(clientID string) error {
+	return m.clients.Delete(clientID)
+}
+
+func (m *manager) IssueAuthorizationCode(request AuthorizationRequest) (string, error) {
+	client, err := m.clients.Find(request.ClientID)
+	if err != nil {
+		if errors.Is(err, ErrClientNotFound) {
+			return "", ErrInvalidClient
+		}
+		return "", err
+	}
+
+	if !client.AllowsGrant(GrantAuthorizationCode) {
+		return "", ErrUnauthorizedClient
+	}
+	if !client.AllowsRedirectURI(request.RedirectURI) {
+		return "", ErrInvalidRequest.WithDescription("redirect_uri is not registered")
+	}
+	if request.UserID == "" {
+		return "", ErrInvalidRequest.WithDescription("user is required")
+	}
+
+	scopes, err := grantedScopes(client, request.Scopes)
+	if err != nil {
+		return "", err
+	}
+
+	method := request.CodeChallengeMethod
+	if method == "" {
+		method = ChallengePlain
+	}
+	if request.CodeChallenge == "" {
+		return "", ErrInvalidRequest.WithDescription("code_challenge is required")
+	}
+	if method != ChallengeS256 && (method != ChallengePlain || !m.config.AllowPlainPKCE) {
+		return "", ErrInvalidRequest.WithDescription("unsupported code_challenge_method")
+	}
+
+	code := randomString(32)
+	err = m.codes.Save(&AuthorizationCode{
+		Hash:                hashValue(code),
+		ClientID:            client.ID,
+		UserID:              request.UserID,
+		RedirectURI:         request.RedirectURI,
+		Scopes:              scopes,
+		CodeChallenge:       request.CodeChallenge,
+		CodeChallengeMethod: method,
+		ExpiresAt:           time.Now().Add(m.config.AuthorizationCodeTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
+
+// authenticateClient checks the client's credentials. Public clients only
+// identify themselves.
+func (m *manager) authenticateClient(clientID, secret string) (*Client, error) {
+	if clientID == "" {
+		return nil, ErrInvalidClient
+	}
+
+	client, err := m.clients.Find(clientID)
+	if err != nil {
+		if errors.Is(err, ErrClientNotFound) {
+			return nil, ErrInvalidClient
+		}
+		return nil, err
+	}
+
+	if client.Public {
+		if secret != "" {
+			return nil, ErrInvalidClient
+		}
+		return client, nil
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashValue(secret)), []byte(client.SecretHash)) != 1 {
+		return nil, ErrInvalidClient
+	}
+	return client, nil
+}
+
+func (m *manager) clientCredentialsGrant(client *Client, scope string) (*TokenResponse, error) {
+	if client.Public || !client.AllowsGrant(GrantClientCredentials) {
+		return nil, ErrUnauthorizedClient
+	}
+
+	scopes, err := grantedScopes(client, strings.Fields(scope))
+	if err != nil {
+		return nil, err
+	}
+
+	// The client acts on its own behalf, so it is also the subject
+	token, err := m.jwt.GenerateAccessToken(jwt.Claims{
+		UserID:   client.ID,
+		Subject:  client.ID,
+		ClientID: client.ID,
+		Scopes:   scopes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tokenResponse(&jwt.TokenPair{AccessToken: token}, scopes), nil
+}
+
+func (m *manager) authorizationCodeGrant(client *Client, code, redirectURI, verifier string) (*TokenResponse, error) {
+	if !client.AllowsGrant(GrantAuthorizationCode) {
+		return nil, ErrUnauthorizedClient
+	}
+	if code == "" {
+		return nil, ErrInvalidRequest.WithDescription("code is required")
+	}
+
+	// Only the client the code was issued to, at the same redirect URI, can
+	// consume it
+	record, err := m.codes.Consume(hashValue(code), client.ID, redirectURI, time.Now())
+	if err != nil {
+		if errors.Is(err, ErrCodeNotFound) || errors.Is(err, ErrCodeUsed) {
+			return nil, ErrInvalidGrant
+		}
+		return nil, err
+	}
+
+	if !time.Now().Before(record.ExpiresAt) {
+		return nil, ErrInvalidGrant.WithDescription("code expired")
+	}
+	if !verifyCodeChallenge(record.CodeChallenge, record.CodeChallengeMethod, verifier) {
+		return nil, ErrInvalidGrant.WithDescription("code_verifier does not match")
+	}
+
+	var opts []jwt.TokenPairOption
+	if client.AllowsGrant(GrantRefreshToken) {
+		opts = append(opts, jwt.WithRefreshToken())
+	}
+
+	pair, err := m.jwt.GenerateTokenPair(jwt.Claims{
+		UserID:   record.UserID,
+		ClientID: client.ID,
+		Scopes:   record.Scopes,
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return tokenResponse(pair, record.Scopes), nil
+}
+
+func (m *manager) refreshTokenGrant(client *Client, refreshToken, scope string) (*TokenResponse, error) {
+	if !client.AllowsGrant(GrantRefreshToken) {
+		return nil, ErrUnauthorizedClient
+	}
+	if refreshToken == "" {
+		return nil, ErrInvalidRequest.WithDescription("refresh_token is required")
+	}
+
+	validated, err := m.jwt.ValidateToken(refreshToken)
+	if err != nil || validated.Claims.TokenType != string(jwt.RefreshTokenType) {
+		return nil, ErrInvalidGrant
+	}
+	if validated.Claims.ClientID != client.ID {
+		return nil, ErrInvalidGrant
+	}
+
+	// A refresh may narrow the original grant but not widen it
+	scopes := validated.Claims.Scopes
+	requested := strings.Fields(scope)
+	if len(requested) > 0 {
+		for _, s := range requested {
+			if !validated.Claims.HasScope(s) {
+				return nil, ErrInvalidScope
+			}
+		}
+		scopes = requested
+	}
+
+	pair, err := m.jwt.RefreshTokenPair(refreshToken)
+	if err != nil {
+		return nil, ErrInvalidGrant
+	}
+
+	// The refresh token keeps the original grant, as RFC 6749 section 6
+	// requires; only the scope of the new access token is narrowed, the rest
+	// carries over like in RefreshTokenPair
+	if len(requested) > 0 {
+		original := validated.Claims
+		pair.AccessToken, err = m.jwt.GenerateAccessToken(jwt.Claims{
+			UserID:    original.UserID,
+			ClientID:  client.ID,
+			DeviceID:  original.DeviceID,
+			SessionID: original.SessionID,
+			Scopes:    scopes,
+			Metadata:  original.Metadata,
+			Issuer:    original.Issuer,
+			Audience:  original.Audience,
+			Audiences: original.Audiences,
+			Subject:   original.Subject,
+			Custom:    original.Custom,
+			Extension: original.Extension,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tokenResponse(pair, scopes), nil
+}
+
+func (m *manager) introspect(token string) *IntrospectionResponse {
+	validated, err := m.jwt.ValidateToken(token)
+	if err != nil || !validated.IsValid {
+		return &IntrospectionResponse{Active: false}
+	}
+
+	claims := validated.Claims
+	response := &IntrospectionResponse{
+		Active:    true,
+		Scope:     strings.Join(claims.Scopes, " "),
+		ClientID:  claims.ClientID,
+		Username:  claims.UserID,
+		ExpiresAt: claims.ExpiresAt,
+		IssuedAt:  claims.IssuedAt,
+		NotBefore: claims.NotBefore,
+		Subject:   claims.Subject,
+		Audience:  claims.Audiences,
+		Issuer:    claims.Issuer,
+		ID:        claims.ID,
+	}
+	if claims.TokenType == string(jwt.AccessTokenType) {
+		response.TokenType = "Bearer"
+	} else {
+		response.TokenType = claims.TokenType
+	}
+	return response
+}
+
+// revoke invalidates a token issued to client. Unknown, invalid and foreign
+// tokens are ignored, as RFC 7009 does not let them be told apart.
+func (m *manager) revoke(client *Client, token string) error {
+	validated, err := m.jwt.ValidateToken(token, jwt.SkipExpiration())
+	if err != nil || validated.Claims.ClientID != client.ID || validated.Claims.ID == "" {
+		return nil
+	}
+	return m.jwt.Revoke(validated.Claims.ID)
+}
+
+// grantedScopes checks requested scopes against those registered for the
+// client. No requested scopes grants all of them.
+func grantedScopes(client *Client, requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return client.Scopes, nil
+	}
+	for _, scope := range requested {
+		if !contains(client.Scopes, scope) {
+			return nil, ErrInvalidScope.WithDescription(scope)
+		}
+	}
+	return requested, nil
+}
+
+func verifyCodeChallenge(challenge, method, verifier string) bool {
+	// RFC 7636 section 4.1 bounds the verifier length
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+
+	switch method {
+	case ChallengeS256:
+		sum := sha256.Sum256([]byte(verifier))
+		expected := base64.RawURLEncoding.EncodeToString(sum[:])
+		return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+	case ChallengePlain:
+		return subtle.ConstantTimeCompare([]byte(verifier), []byte(challenge)) == 1
+	default:
+		return false
+	}
+}
+
+func tokenResponse(pair *jwt.TokenPair, scopes []string) *TokenResponse {
+	response := &TokenResponse{
+		AccessToken: pair.AccessToken.Token,
+		TokenType:   pair.AccessToken.TokenType,
+		ExpiresIn:   pair.AccessToken.ExpiresIn,
+		Scope:       strings.Join(scopes, " "),
+	}
+	if pair.HasRefreshToken() {
+		response.RefreshToken = pair.RefreshToken.Token
+	}
+	return response
+}
+
+func randomString(size int) string {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// hashValue hashes client secrets and codes for storage. Both are random
+// with enough entropy that a fast hash suffices.
+func hashValue(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,320 @@
+package oauth2
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/upnext-fng/fulcrum/security/jwt"
+)
+
+const testVerifier = "dBjftJeZ4CVP-mJ92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+func newTestService(t *testing.T) (Service, *echo.Echo) {
+	t.Helper()
+
+	jwtService := jwt.NewJWTService(jwt.Config{
+		Secret:          "test-secret-key-123",
+		AccessTokenTTL:  time.Hour,
+		RefreshTokenTTL: time.Hour * 24,
+	})
+	service := NewManager(Config{}, NewMemoryClientStore(), NewMemoryCodeStore(), jwtService)
+
+	e := echo.New()
+	service.RegisterRoutes(e)
+	return service, e
+}
+
+func post(e *echo.Echo, path string, form url.Values, clientID, secret string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	if secret != "" {
+		req.SetBasicAuth(clientID, secret)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func decode[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+
+	var v T
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &v))
+	return v
+}
+
+func TestOAuth2_ClientCredentials(t *testing.T) {
+	service, e := newTestService(t)
+
+	client, err := service.RegisterClient(RegisterClientRequest{
+		Name:       "billing",
+		GrantTypes: []string{GrantClientCredentials},
+		Scopes:     []string{"invoices:read", "invoices:write"},
+	})
+	require.NoError(t, err)
+
+	rec := post(e, "/oauth/token", url.Values{"grant_type": {"client_credentials"}, "scope": {"invoices:read"}}, client.ClientID, client.ClientSecret)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+
+	token := decode[TokenResponse](t, rec)
+	assert.Equal(t, "Bearer", token.TokenType)
+	assert.Equal(t, "invoices:read", token.Scope)
+	assert.Empty(t, token.RefreshToken)
+
+	rec = post(e, "/oauth/introspect", url.Values{"token": {token.AccessToken}}, client.ClientID, client.ClientSecret)
+	require.Equal(t, http.StatusOK, rec.Code)
+	introspection := decode[IntrospectionResponse](t, rec)
+	assert.True(t, introspection.Active)
+	assert.Equal(t, client.ClientID, introspection.ClientID)
+	assert.Equal(t, "invoices:read", introspection.Scope)
+
+	rec = post(e, "/oauth/revoke", url.Values{"token": {token.AccessToken}}, client.ClientID, client.ClientSecret)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = post(e, "/oauth/introspect", url.Values{"token": {token.AccessToken}}, client.ClientID, client.ClientSecret)
+	assert.False(t, decode[IntrospectionResponse](t, rec).Active)
+
+	// Scopes beyond the registration and wrong secrets are rejected
+	rec = post(e, "/oauth/token", url.Values{"grant_type": {"client_credentials"}, "scope": {"admin"}}, client.ClientID, client.ClientSecret)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "invalid_scope", decode[Error](t, rec).Code)
+
+	rec = post(e, "/oauth/token", url.Values{"grant_type": {"client_credentials"}}, client.ClientID, "wrong")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "invalid_client", decode[Error](t, rec).Code)
+	assert.NotEmpty(t, rec.Header().Get(echo.HeaderWWWAuthenticate))
+}
+
+func TestOAuth2_AuthorizationCodeWithPKCE(t *testing.T) {
+	service, e := newTestService(t)
+
+	client, err := service.RegisterClient(RegisterClientRequest{
+		Name:         "cli",
+		RedirectURIs: []string{"http://127.0.0.1:8400/callback"},
+		Scopes:       []string{"profile"},
+		Public:       true,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, client.ClientSecret)
+
+	sum := sha256.Sum256([]byte(testVerifier))
+	code, err := service.IssueAuthorizationCode(AuthorizationRequest{
+		ClientID:            client.ClientID,
+		UserID:              "test-user-123",
+		RedirectURI:         "http://127.0.0.1:8400/callback",
+		CodeChallenge:       base64.RawURLEncoding.EncodeToString(sum[:]),
+		CodeChallengeMethod: ChallengeS256,
+	})
+	require.NoError(t, err)
+
+	exchange := url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {client.ClientID},
+		"code":          {code},
+		"redirect_uri":  {"http://127.0.0.1:8400/callback"},
+		"code_verifier": {strings.Repeat("x", 43)},
+	}
+	rec := post(e, "/oauth/token", exchange, "", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "invalid_grant", decode[Error](t, rec).Code)
+
+	// The failed attempt consumed the code
+	exchange.Set("code_verifier", testVerifier)
+	rec = post(e, "/oauth/token", exchange, "", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	code, err = service.IssueAuthorizationCode(AuthorizationRequest{
+		ClientID:            client.ClientID,
+		UserID:              "test-user-123",
+		RedirectURI:         "http://127.0.0.1:8400/callback",
+		CodeChallenge:       base64.RawURLEncoding.EncodeToString(sum[:]),
+		CodeChallengeMethod: ChallengeS256,
+	})
+	require.NoError(t, err)
+
+	exchange.Set("code", code)
+	rec = post(e, "/oauth/token", exchange, "", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	token := decode[TokenResponse](t, rec)
+	assert.NotEmpty(t, token.AccessToken)
+	require.NotEmpty(t, token.RefreshToken)
+	assert.Equal(t, "profile", token.Scope)
+
+	rec = post(e, "/oauth/token", exchange, "", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	refresh := url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {client.ClientID},
+		"refresh_token": {token.RefreshToken},
+	}
+	rec = post(e, "/oauth/token", refresh, "", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.NotEmpty(t, decode[TokenResponse](t, rec).AccessToken)
+
+	refresh.Set("scope", "admin")
+	rec = post(e, "/oauth/token", refresh, "", "")
+	assert.Equal(t, "invalid_scope", decode[Error](t, rec).Code)
+}
+
+func TestOAuth2_IssueAuthorizationCodeValidation(t *testing.T) {
+	service, _ := newTestService(t)
+
+	client, err := service.RegisterClient(RegisterClientRequest{
+		RedirectURIs: []string{"https://app.example.com/callback"},
+		Public:       true,
+	})
+	require.NoError(t, err)
+
+	request := AuthorizationRequest{
+		ClientID:      client.ClientID,
+		UserID:        "test-user-123",
+		RedirectURI:   "https://evil.example.com/callback",
+		CodeChallenge: testVerifier,
+	}
+	_, err = service.IssueAuthorizationCode(request)
+	assert.ErrorIs(t, err, ErrInvalidRequest)
+
+	// Plain challenges are off by default
+	request.RedirectURI = "https://app.example.com/callback"
+	_, err = service.IssueAuthorizationCode(request)
+	assert.ErrorIs(t, err, ErrInvalidRequest)
+
+	_, err = service.RegisterClient(RegisterClientRequest{Public: true, GrantTypes: []string{GrantClientCredentials}})
+	assert.ErrorIs(t, err, ErrInvalidRequest)
+}
+
+func TestOAuth2_CodeBoundToClient(t *testing.T) {
+	service, e := newTestService(t)
+
+	redirect := "https://app.example.com/callback"
+	owner, err := service.RegisterClient(RegisterClientRequest{RedirectURIs: []string{redirect}, Scopes: []string{"profile", "email"}})
+	require.NoError(t, err)
+	attacker, err := service.RegisterClient(RegisterClientRequest{RedirectURIs: []string{redirect}})
+	require.NoError(t, err)
+
+	sum := sha256.Sum256([]byte(testVerifier))
+	code, err := service.IssueAuthorizationCode(AuthorizationRequest{
+		ClientID:            owner.ClientID,
+		UserID:              "test-user-123",
+		RedirectURI:         redirect,
+		CodeChallenge:       base64.RawURLEncoding.EncodeToString(sum[:]),
+		CodeChallengeMethod: ChallengeS256,
+	})
+	require.NoError(t, err)
+
+	exchange := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirect},
+		"code_verifier": {testVerifier},
+	}
+
+	// Another client cannot exchange, or burn, the code
+	rec := post(e, "/oauth/token", exchange, attacker.ClientID, attacker.ClientSecret)
+	assert.Equal(t, "invalid_grant", decode[Error](t, rec).Code)
+
+	rec = post(e, "/oauth/token", exchange, owner.ClientID, owner.ClientSecret)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	token := decode[TokenResponse](t, rec)
+	assert.Equal(t, "profile email", token.Scope)
+
+	// Refreshing with a narrower scope narrows the new access token
+	rec = post(e, "/oauth/token", url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {token.RefreshToken},
+		"scope":         {"profile"},
+	}, owner.ClientID, owner.ClientSecret)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	narrowed := decode[TokenResponse](t, rec)
+	assert.Equal(t, "profile", narrowed.Scope)
+
+	rec = post(e, "/oauth/introspect", url.Values{"token": {narrowed.AccessToken}}, owner.ClientID, owner.ClientSecret)
+	assert.Equal(t, "profile", decode[IntrospectionResponse](t, rec).Scope)
+}
+
+func TestOAuth2_IntrospectionRequiresConfidentialClient(t *testing.T) {
+	service, e := newTestService(t)
+
+	public, err := service.RegisterClient(RegisterClientRequest{RedirectURIs: []string{"http://127.0.0.1/callback"}, Public: true})
+	require.NoError(t, err)
+	confidential, err := service.RegisterClient(RegisterClientRequest{GrantTypes: []string{GrantClientCredentials}})
+	require.NoError(t, err)
+
+	rec := post(e, "/oauth/token", url.Values{"grant_type": {"client_credentials"}}, confidential.ClientID, confidential.ClientSecret)
+	require.Equal(t, http.StatusOK, rec.Code)
+	token := decode[TokenResponse](t, rec)
+
+	rec = post(e, "/oauth/introspect", url.Values{"token": {token.AccessToken}, "client_id": {public.ClientID}}, "", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "invalid_client", decode[Error](t, rec).Code)
+}
+
+func TestOAuth2_NarrowedRefreshKeepsClaims(t *testing.T) {
+	jwtService := jwt.NewJWTService(jwt.Config{
+		Secret:          "test-secret-key-123",
+		AccessTokenTTL:  time.Hour,
+		RefreshTokenTTL: time.Hour * 24,
+	})
+	service := NewManager(Config{}, NewMemoryClientStore(), NewMemoryCodeStore(), jwtService)
+	e := echo.New()
+	service.RegisterRoutes(e)
+
+	client, err := service.RegisterClient(RegisterClientRequest{
+		Name:         "cli",
+		RedirectURIs: []string{"http://127.0.0.1:8400/callback"},
+		Scopes:       []string{"profile", "email"},
+		Public:       true,
+	})
+	require.NoError(t, err)
+
+	refreshToken, err := jwtService.GenerateRefreshToken(jwt.Claims{
+		UserID:    "test-user-123",
+		ClientID:  client.ClientID,
+		SessionID: "session-1",
+		DeviceID:  "device-1",
+		Scopes:    []string{"profile", "email"},
+		Issuer:    "https://auth.example.com",
+		Audiences: []string{"api"},
+		Metadata:  map[string]interface{}{"tenant": "acme"},
+	})
+	require.NoError(t, err)
+
+	rec := post(e, "/oauth/token", url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {client.ClientID},
+		"refresh_token": {refreshToken.Token},
+		"scope":         {"profile"},
+	}, "", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	token := decode[TokenResponse](t, rec)
+	assert.Equal(t, "profile", token.Scope)
+
+	validated, err := jwtService.ValidateToken(token.AccessToken)
+	require.NoError(t, err)
+	claims := validated.Claims
+	assert.Equal(t, []string{"profile"}, claims.Scopes)
+	assert.Equal(t, "test-user-123", claims.Subject)
+	assert.Equal(t, "session-1", claims.SessionID)
+	assert.Equal(t, "device-1", claims.DeviceID)
+	assert.Equal(t, "https://auth.example.com", claims.Issuer)
+	assert.Equal(t, []string{"api"}, claims.Audiences)
+	assert.Equal(t, map[string]interface{}{"tenant": "acme"}, claims.Metadata)
+	assert.Equal(t, string(jwt.AccessTokenType), claims.TokenType)
+
+	// Revoking the session covers the narrowed token too
+	require.NoError(t, jwtService.RevokeSession("session-1"))
+	_, err = jwtService.ValidateToken(token.AccessToken)
+	assert.ErrorIs(t, err, jwt.ErrTokenRevoked)
+}
@@ -0,0 +1,10 @@
+package oauth2
+
+import (
+	"github.com/upnext-fng/fulcrum/database"
+	"github.com/upnext-fng/fulcrum/security/jwt"
+)
+
+func NewService(config Config, db database.DatabaseService, jwtService jwt.Service) Service {
+	return NewManager(config, NewGormClientStore(db), NewGormCodeStore(db), jwtService)
+}
@@ -0,0 +1,112 @@
+package oauth2
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/upnext-fng/fulcrum/database"
+	"gorm.io/gorm"
+)
+
+type gormClientStore struct {
+	db database.DatabaseService
+}
+
+// NewGormClientStore stores clients in the oauth_clients table. Migrate
+// Client before use.
+func NewGormClientStore(db database.DatabaseService) ClientStore {
+	return &gormClientStore{
+		db: db,
+	}
+}
+
+func (s *gormClientStore) Create(client *Client) error {
+	return s.db.Connection().Create(client).Error
+}
+
+func (s *gormClientStore) Find(clientID string) (*Client, error) {
+	var client Client
+	if err := s.db.Connection().Where("id = ?", clientID).First(&client).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrClientNotFound
+		}
+		return nil, err
+	}
+	return &client, nil
+}
+
+func (s *gormClientStore) Delete(clientID string) error {
+	result := s.db.Connection().Where("id = ?", clientID).Delete(&Client{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrClientNotFound
+	}
+	return nil
+}
+
+type gormCodeStore struct {
+	db        database.DatabaseService
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// NewGormCodeStore stores authorization codes in the
+// oauth_authorization_codes table. Migrate AuthorizationCode before use.
+func NewGormCodeStore(db database.DatabaseService) CodeStore {
+	return &gormCodeStore{
+		db: db,
+	}
+}
+
+func (s *gormCodeStore) Save(code *AuthorizationCode) error {
+	s.sweep(time.Now())
+	return s.db.Connection().Create(code).Error
+}
+
+func (s *gormCodeStore) Consume(hash, clientID, redirectURI string, usedAt time.Time) (*AuthorizationCode, error) {
+	var code AuthorizationCode
+	err := s.db.Connection().Transaction(func(tx *gorm.DB) error {
+		// The conditional update makes concurrent exchanges of one code race
+		// for a single row
+		result := tx.Model(&AuthorizationCode{}).
+			Where("hash = ? AND client_id = ? AND redirect_uri = ? AND used_at IS NULL", hash, clientID, redirectURI).
+			Update("used_at", usedAt)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		err := tx.Where("hash = ? AND client_id = ? AND redirect_uri = ?", hash, clientID, redirectURI).First(&code).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCodeNotFound
+			}
+			return err
+		}
+
+		if result.RowsAffected == 0 {
+			return ErrCodeUsed
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// sweep deletes expired codes at most once a minute
+func (s *gormCodeStore) sweep(now time.Time) {
+	s.mu.Lock()
+	if now.Sub(s.lastSweep) < time.Minute {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = now
+	s.mu.Unlock()
+
+	// Best effort, the next sweep retries
+	s.db.Connection().Where("expires_at < ?", now).Delete(&AuthorizationCode{})
+}
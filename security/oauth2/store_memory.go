@@ -0,0 +1,107 @@
+package oauth2
+
+import (
+	"sync"
+	"time"
+)
+
+type memoryClientStore struct {
+	mu      sync.RWMutex
+	clients map[string]*Client
+}
+
+// NewMemoryClientStore creates a process-local client store, useful for tests
+func NewMemoryClientStore() ClientStore {
+	return &memoryClientStore{
+		clients: make(map[string]*Client),
+	}
+}
+
+func (s *memoryClientStore) Create(client *Client) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := *client
+	s.clients[client.ID] = &stored
+	return nil
+}
+
+func (s *memoryClientStore) Find(clientID string) (*Client, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	client, ok := s.clients[clientID]
+	if !ok {
+		return nil, ErrClientNotFound
+	}
+
+	found := *client
+	return &found, nil
+}
+
+func (s *memoryClientStore) Delete(clientID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.clients[clientID]; !ok {
+		return ErrClientNotFound
+	}
+	delete(s.clients, clientID)
+	return nil
+}
+
+type memoryCodeStore struct {
+	mu        sync.Mutex
+	codes     map[string]*AuthorizationCode
+	lastSweep time.Time
+}
+
+// NewMemoryCodeStore creates a process-local code store. Codes are only
+// exchangeable on the instance that issued them.
+func NewMemoryCodeStore() CodeStore {
+	return &memoryCodeStore{
+		codes: make(map[string]*AuthorizationCode),
+	}
+}
+
+func (s *memoryCodeStore) Save(code *AuthorizationCode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(time.Now())
+
+	stored := *code
+	s.codes[code.Hash] = &stored
+	return nil
+}
+
+func (s *memoryCodeStore) Consume(hash, clientID, redirectURI string, usedAt time.Time) (*AuthorizationCode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	code, ok := s.codes[hash]
+	if !ok || code.ClientID != clientID || code.RedirectURI != redirectURI {
+		return nil, ErrCodeNotFound
+	}
+	if code.UsedAt != nil {
+		return nil, ErrCodeUsed
+	}
+	code.UsedAt = &usedAt
+
+	consumed := *code
+	return &consumed, nil
+}
+
+// sweep drops expired codes at most once a minute
+func (s *memoryCodeStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for hash, code := range s.codes {
+		if now.After(code.ExpiresAt) {
+			delete(s.codes, hash)
+		}
+	}
+}
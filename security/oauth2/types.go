@@ -0,0 +1,164 @@
+package oauth2
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Grant types
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantClientCredentials = "client_credentials"
+	GrantRefreshToken      = "refresh_token"
+)
+
+// PKCE code challenge methods
+const (
+	ChallengeS256  = "S256"
+	ChallengePlain = "plain"
+)
+
+// Client is a registered OAuth client. Public clients, such as native and
+// browser apps, have no secret and must use PKCE.
+type Client struct {
+	ID           string    `json:"client_id" gorm:"primaryKey;size:64"`
+	SecretHash   string    `json:"-" gorm:"size:64"`
+	Name         string    `json:"client_name" gorm:"size:255"`
+	RedirectURIs []string  `json:"redirect_uris" gorm:"serializer:json"`
+	GrantTypes   []string  `json:"grant_types" gorm:"serializer:json"`
+	Scopes       []string  `json:"scopes" gorm:"serializer:json"`
+	Public       bool      `json:"public"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (Client) TableName() string {
+	return "oauth_clients"
+}
+
+func (c *Client) AllowsGrant(grantType string) bool {
+	return contains(c.GrantTypes, grantType)
+}
+
+func (c *Client) AllowsRedirectURI(uri string) bool {
+	return contains(c.RedirectURIs, uri)
+}
+
+// AuthorizationCode is an issued code. Only the SHA-256 hash of the code is
+// stored.
+type AuthorizationCode struct {
+	Hash                string    `gorm:"primaryKey;size:64"`
+	ClientID            string    `gorm:"index;size:64;not null"`
+	UserID              string    `gorm:"size:255;not null"`
+	RedirectURI         string    `gorm:"size:2048"`
+	Scopes              []string  `gorm:"serializer:json"`
+	CodeChallenge       string    `gorm:"size:128"`
+	CodeChallengeMethod string    `gorm:"size:8"`
+	ExpiresAt           time.Time `gorm:"index"`
+	UsedAt              *time.Time
+}
+
+func (AuthorizationCode) TableName() string {
+	return "oauth_authorization_codes"
+}
+
+type RegisterClientRequest struct {
+	Name         string   `json:"client_name"`
+	RedirectURIs []string `json:"redirect_uris"`
+	GrantTypes   []string `json:"grant_types"`
+	Scopes       []string `json:"scopes"`
+	Public       bool     `json:"public"`
+}
+
+// RegisteredClient holds a new client. ClientSecret is the only copy of the
+// secret and is empty for public clients.
+type RegisteredClient struct {
+	ClientID     string  `json:"client_id"`
+	ClientSecret string  `json:"client_secret,omitempty"`
+	Client       *Client `json:"client"`
+}
+
+type AuthorizationRequest struct {
+	ClientID            string
+	UserID              string
+	RedirectURI         string
+	Scopes              []string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+// TokenResponse is the RFC 6749 section 5.1 response
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// IntrospectionResponse is the RFC 7662 section 2.2 response
+type IntrospectionResponse struct {
+	Active    bool     `json:"active"`
+	Scope     string   `json:"scope,omitempty"`
+	ClientID  string   `json:"client_id,omitempty"`
+	Username  string   `json:"username,omitempty"`
+	TokenType string   `json:"token_type,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	Subject   string   `json:"sub,omitempty"`
+	Audience  []string `json:"aud,omitempty"`
+	Issuer    string   `json:"iss,omitempty"`
+	ID        string   `json:"jti,omitempty"`
+}
+
+// Error is an RFC 6749 section 5.2 error response. Errors compare equal by
+// code, so errors.Is(err, ErrInvalidGrant) holds for any described variant.
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+	Status      int    `json:"-"`
+}
+
+func (e *Error) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithDescription returns a copy of e with a human readable description
+func (e *Error) WithDescription(description string) *Error {
+	described := *e
+	described.Description = description
+	return &described
+}
+
+var (
+	ErrInvalidRequest       = &Error{Code: "invalid_request", Status: http.StatusBadRequest}
+	ErrInvalidClient        = &Error{Code: "invalid_client", Status: http.StatusUnauthorized}
+	ErrInvalidGrant         = &Error{Code: "invalid_grant", Status: http.StatusBadRequest}
+	ErrUnauthorizedClient   = &Error{Code: "unauthorized_client", Status: http.StatusBadRequest}
+	ErrUnsupportedGrantType = &Error{Code: "unsupported_grant_type", Status: http.StatusBadRequest}
+	ErrInvalidScope         = &Error{Code: "invalid_scope", Status: http.StatusBadRequest}
+)
+
+var (
+	ErrClientNotFound = errors.New("client not found")
+	ErrCodeNotFound   = errors.New("authorization code not found")
+	ErrCodeUsed       = errors.New("authorization code already used")
+)
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
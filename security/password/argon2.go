@@ -0,0 +1,125 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Defaults follow the second recommended option of RFC 9106
+var defaultArgon2Config = Argon2Config{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 4,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+type argon2Hasher struct {
+	config Argon2Config
+}
+
+func NewArgon2idHasher(config Argon2Config) Hasher {
+	if config.Memory == 0 {
+		config.Memory = defaultArgon2Config.Memory
+	}
+	if config.Iterations == 0 {
+		config.Iterations = defaultArgon2Config.Iterations
+	}
+	if config.Parallelism == 0 {
+		config.Parallelism = defaultArgon2Config.Parallelism
+	}
+	if config.SaltLength == 0 {
+		config.SaltLength = defaultArgon2Config.SaltLength
+	}
+	if config.KeyLength == 0 {
+		config.KeyLength = defaultArgon2Config.KeyLength
+	}
+
+	return &argon2Hasher{
+		config: config,
+	}
+}
+
+func (h *argon2Hasher) ID() string {
+	return AlgorithmArgon2id
+}
+
+func (h *argon2Hasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.config.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	c := h.config
+	encoded := &phcHash{
+		id:      AlgorithmArgon2id,
+		version: argon2.Version,
+		salt:    salt,
+		hash:    argon2.IDKey([]byte(password), salt, c.Iterations, c.Memory, c.Parallelism, c.KeyLength),
+	}
+	return encoded.encode(fmt.Sprintf("m=%d,t=%d,p=%d", c.Memory, c.Iterations, c.Parallelism)), nil
+}
+
+func (h *argon2Hasher) Verify(encoded, password string) error {
+	parsed, params, err := h.parse(encoded)
+	if err != nil {
+		return err
+	}
+
+	key := argon2.IDKey([]byte(password), parsed.salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(parsed.hash)))
+	if subtle.ConstantTimeCompare(key, parsed.hash) != 1 {
+		return ErrMismatchedPassword
+	}
+	return nil
+}
+
+func (h *argon2Hasher) NeedsRehash(encoded string) bool {
+	parsed, params, err := h.parse(encoded)
+	if err != nil {
+		return true
+	}
+
+	return params.Memory != h.config.Memory ||
+		params.Iterations != h.config.Iterations ||
+		params.Parallelism != h.config.Parallelism ||
+		uint32(len(parsed.salt)) != h.config.SaltLength ||
+		uint32(len(parsed.hash)) != h.config.KeyLength
+}
+
+func (h *argon2Hasher) parse(encoded string) (*phcHash, Argon2Config, error) {
+	parsed, err := parsePHC(encoded)
+	if err != nil {
+		return nil, Argon2Config{}, err
+	}
+	if parsed.id != AlgorithmArgon2id {
+		return nil, Argon2Config{}, ErrUnsupportedAlgorithm
+	}
+	if parsed.version != argon2.Version {
+		return nil, Argon2Config{}, fmt.Errorf("%w: argon2 version %d", ErrInvalidHash, parsed.version)
+	}
+
+	memory, err := parsed.uintParam("m", 32)
+	if err != nil {
+		return nil, Argon2Config{}, err
+	}
+	iterations, err := parsed.uintParam("t", 32)
+	if err != nil {
+		return nil, Argon2Config{}, err
+	}
+	parallelism, err := parsed.uintParam("p", 8)
+	if err != nil {
+		return nil, Argon2Config{}, err
+	}
+	if iterations == 0 || parallelism == 0 {
+		return nil, Argon2Config{}, ErrInvalidHash
+	}
+
+	return parsed, Argon2Config{
+		Memory:      uint32(memory),
+		Iterations:  uint32(iterations),
+		Parallelism: uint8(parallelism),
+	}, nil
+}
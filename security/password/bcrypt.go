@@ -0,0 +1,47 @@
+package password
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// defaultBcryptCost replaces costs below the bcrypt minimum, which bcrypt
+// would otherwise silently turn into its own lower default
+const defaultBcryptCost = 12
+
+type bcryptHasher struct {
+	cost int
+}
+
+func NewBcryptHasher(cost int) Hasher {
+	if cost < bcrypt.MinCost {
+		cost = defaultBcryptCost
+	}
+
+	return &bcryptHasher{
+		cost: cost,
+	}
+}
+
+func (h *bcryptHasher) ID() string {
+	return AlgorithmBcrypt
+}
+
+func (h *bcryptHasher) Hash(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	return string(bytes), err
+}
+
+func (h *bcryptHasher) Verify(encoded, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if err != nil && !errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return errors.Join(ErrInvalidHash, err)
+	}
+	return err
+}
+
+func (h *bcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != h.cost
+}
@@ -0,0 +1,123 @@
+package password
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBreachProvider = "hibp"
+	defaultBreachCacheTTL = time.Hour
+)
+
+type breachChecker struct {
+	provider RangeProvider
+	minCount int
+	cacheTTL time.Duration
+
+	mu        sync.Mutex
+	cache     map[string]cachedRange
+	lastSweep time.Time
+}
+
+type cachedRange struct {
+	suffixes  map[string]int
+	expiresAt time.Time
+}
+
+func newBreachChecker(config BreachConfig) (*breachChecker, error) {
+	provider := config.RangeProvider
+	if provider == nil {
+		var err error
+		if provider, err = newRangeProvider(config); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.MinCount <= 0 {
+		config.MinCount = 1
+	}
+	if config.CacheTTL == 0 {
+		config.CacheTTL = defaultBreachCacheTTL
+	}
+
+	return &breachChecker{
+		provider: provider,
+		minCount: config.MinCount,
+		cacheTTL: config.CacheTTL,
+		cache:    make(map[string]cachedRange),
+	}, nil
+}
+
+func newRangeProvider(config BreachConfig) (RangeProvider, error) {
+	switch provider := config.Provider; provider {
+	case "", defaultBreachProvider:
+		return NewHIBPRangeProvider(config.APIURL, config.Timeout), nil
+	case "file":
+		return NewFileRangeProvider(config.File)
+	default:
+		return nil, fmt.Errorf("unknown breach provider %q", provider)
+	}
+}
+
+// count returns how often password appears in the provider's breaches
+func (b *breachChecker) count(password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	suffixes, err := b.lookup(prefix)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrBreachCheckFailed, err)
+	}
+	return suffixes[suffix], nil
+}
+
+func (b *breachChecker) breached(password string) (bool, error) {
+	count, err := b.count(password)
+	return count >= b.minCount, err
+}
+
+func (b *breachChecker) lookup(prefix string) (map[string]int, error) {
+	now := time.Now()
+	if b.cacheTTL > 0 {
+		b.mu.Lock()
+		b.sweep(now)
+		cached, ok := b.cache[prefix]
+		b.mu.Unlock()
+
+		if ok && now.Before(cached.expiresAt) {
+			return cached.suffixes, nil
+		}
+	}
+
+	suffixes, err := b.provider.Range(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.cacheTTL > 0 {
+		b.mu.Lock()
+		b.cache[prefix] = cachedRange{suffixes: suffixes, expiresAt: now.Add(b.cacheTTL)}
+		b.mu.Unlock()
+	}
+	return suffixes, nil
+}
+
+// sweep drops expired ranges at most once a minute. Callers hold mu.
+func (b *breachChecker) sweep(now time.Time) {
+	if now.Sub(b.lastSweep) < time.Minute {
+		return
+	}
+	b.lastSweep = now
+
+	for prefix, cached := range b.cache {
+		if !now.Before(cached.expiresAt) {
+			delete(b.cache, prefix)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package password
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+type fileRangeProvider struct {
+	path string
+}
+
+// NewFileRangeProvider reads a local hash file, such as the downloadable
+// Pwned Passwords list ordered by hash. Each line holds an uppercase SHA-1
+// hash, optionally followed by ":COUNT", and lines must be sorted. Ranges
+// are found by binary search, so the file is never loaded into memory.
+func NewFileRangeProvider(path string) (RangeProvider, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	return &fileRangeProvider{
+		path: path,
+	}, nil
+}
+
+func (p *fileRangeProvider) Range(prefix string) (map[string]int, error) {
+	prefix = strings.ToUpper(prefix)
+
+	file, err := os.Open(p.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+
+	// Find the first line whose hash sorts at or after the prefix
+	var searchErr error
+	offset := sort.Search(int(size), func(i int) bool {
+		_, line, err := lineAfter(file, int64(i), size)
+		if err != nil {
+			searchErr = err
+			return true
+		}
+		return line == "" || strings.ToUpper(line) >= prefix
+	})
+	if searchErr != nil {
+		return nil, searchErr
+	}
+
+	start, _, err := lineAfter(file, int64(offset), size)
+	if err != nil {
+		return nil, err
+	}
+
+	suffixes := make(map[string]int)
+	scanner := bufio.NewScanner(io.NewSectionReader(file, start, size-start))
+	for scanner.Scan() {
+		hash, count, ok := parseHashLine(scanner.Text())
+		if !ok {
+			continue
+		}
+		if !strings.HasPrefix(hash, prefix) {
+			break
+		}
+		suffixes[hash[len(prefix):]] = count
+	}
+	return suffixes, scanner.Err()
+}
+
+// lineAfter returns the first line starting at or after offset, or an empty
+// line at the end of the file
+func lineAfter(file *os.File, offset, size int64) (int64, string, error) {
+	start := offset
+	if offset > 0 {
+		// Skip the rest of the line offset falls into, unless offset is
+		// already the start of a line
+		reader := bufio.NewReader(io.NewSectionReader(file, offset-1, size-offset+1))
+		skipped, err := reader.ReadString('\n')
+		if err == io.EOF {
+			return size, "", nil
+		}
+		if err != nil {
+			return 0, "", err
+		}
+		start = offset - 1 + int64(len(skipped))
+	}
+
+	reader := bufio.NewReader(io.NewSectionReader(file, start, size-start))
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return 0, "", err
+	}
+	return start, strings.TrimSpace(line), nil
+}
@@ -0,0 +1,86 @@
+package password
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultHIBPURL     = "https://api.pwnedpasswords.com/range/"
+	defaultHIBPTimeout = 5 * time.Second
+)
+
+type hibpRangeProvider struct {
+	url    string
+	client *http.Client
+}
+
+// NewHIBPRangeProvider queries a Pwned Passwords compatible range API.
+// Responses are padded with fake entries so their size reveals nothing.
+func NewHIBPRangeProvider(url string, timeout time.Duration) RangeProvider {
+	if url == "" {
+		url = defaultHIBPURL
+	}
+	if !strings.HasSuffix(url, "/") {
+		url += "/"
+	}
+	if timeout <= 0 {
+		timeout = defaultHIBPTimeout
+	}
+
+	return &hibpRangeProvider{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *hibpRangeProvider) Range(prefix string) (map[string]int, error) {
+	req, err := http.NewRequest(http.MethodGet, p.url+prefix, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Add-Padding", "true")
+	req.Header.Set("User-Agent", "fulcrum-password")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("range request returned %s", resp.Status)
+	}
+
+	suffixes := make(map[string]int)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		suffix, count, ok := parseHashLine(scanner.Text())
+		// Padding entries have a count of zero
+		if ok && count > 0 {
+			suffixes[suffix] = count
+		}
+	}
+	return suffixes, scanner.Err()
+}
+
+// parseHashLine parses "HASH:COUNT" lines. A missing count counts as one.
+func parseHashLine(line string) (string, int, bool) {
+	hash, countText, found := strings.Cut(strings.TrimSpace(line), ":")
+	if hash == "" {
+		return "", 0, false
+	}
+	if !found {
+		return strings.ToUpper(hash), 1, true
+	}
+
+	count, err := strconv.Atoi(countText)
+	if err != nil {
+		return "", 0, false
+	}
+	return strings.ToUpper(hash), count, true
+}
@@ -0,0 +1,141 @@
+package password
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// writeHashFile writes a sorted hash file with some filler entries
+func writeHashFile(t *testing.T, counts map[string]int) string {
+	t.Helper()
+
+	var lines []string
+	for password, count := range counts {
+		lines = append(lines, fmt.Sprintf("%s:%d", sha1Hex(password), count))
+	}
+	for i := 0; i < 200; i++ {
+		lines = append(lines, fmt.Sprintf("%s:%d", sha1Hex(fmt.Sprintf("filler-%d", i)), i+1))
+	}
+	sort.Strings(lines)
+
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600))
+	return path
+}
+
+type countingProvider struct {
+	RangeProvider
+	calls int
+	err   error
+}
+
+func (p *countingProvider) Range(prefix string) (map[string]int, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.RangeProvider.Range(prefix)
+}
+
+func TestBreach_FileProvider(t *testing.T) {
+	path := writeHashFile(t, map[string]int{"hunter2hunter2": 42, "rarely-seen-pass": 1})
+
+	provider, err := NewFileRangeProvider(path)
+	require.NoError(t, err)
+
+	hash := sha1Hex("hunter2hunter2")
+	suffixes, err := provider.Range(hash[:5])
+	require.NoError(t, err)
+	assert.Equal(t, 42, suffixes[hash[5:]])
+
+	for i := 0; i < 200; i += 37 {
+		hash := sha1Hex(fmt.Sprintf("filler-%d", i))
+		suffixes, err := provider.Range(hash[:5])
+		require.NoError(t, err)
+		assert.Equal(t, i+1, suffixes[hash[5:]])
+	}
+
+	suffixes, err = provider.Range(sha1Hex("not in the file")[:5])
+	require.NoError(t, err)
+	assert.NotContains(t, suffixes, sha1Hex("not in the file")[5:])
+
+	_, err = NewFileRangeProvider(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
+
+func TestBreach_ValidatePassword(t *testing.T) {
+	path := writeHashFile(t, map[string]int{"hunter2hunter2": 42, "rarely-seen-pass": 1})
+	service := NewManager(Config{Breach: BreachConfig{Enabled: true, Provider: "file", File: path, MinCount: 2}})
+
+	err := service.ValidatePassword("hunter2hunter2")
+	var policyErr *PolicyError
+	require.ErrorAs(t, err, &policyErr)
+	assert.True(t, policyErr.Has(ViolationBreachedPassword))
+
+	// Below MinCount
+	assert.NoError(t, service.ValidatePassword("rarely-seen-pass"))
+	assert.NoError(t, service.ValidatePassword("correct horse battery"))
+
+	count, err := service.BreachCount("hunter2hunter2")
+	require.NoError(t, err)
+	assert.Equal(t, 42, count)
+
+	_, err = NewManager(Config{}).BreachCount("hunter2hunter2")
+	assert.ErrorIs(t, err, ErrBreachDisabled)
+}
+
+func TestBreach_CacheAndFailureModes(t *testing.T) {
+	path := writeHashFile(t, map[string]int{"hunter2hunter2": 42})
+	file, err := NewFileRangeProvider(path)
+	require.NoError(t, err)
+
+	provider := &countingProvider{RangeProvider: file}
+	service := NewManager(Config{Breach: BreachConfig{Enabled: true, RangeProvider: provider}})
+
+	for i := 0; i < 3; i++ {
+		assert.Error(t, service.ValidatePassword("hunter2hunter2"))
+	}
+	assert.Equal(t, 1, provider.calls)
+
+	provider.err = errors.New("connection refused")
+	assert.NoError(t, service.ValidatePassword("correct horse battery"), "fails open by default")
+
+	closed := NewManager(Config{Breach: BreachConfig{Enabled: true, RangeProvider: provider, FailClosed: true}})
+	assert.ErrorIs(t, closed.ValidatePassword("correct horse battery"), ErrBreachCheckFailed)
+}
+
+func TestBreach_HIBPProvider(t *testing.T) {
+	hash := sha1Hex("hunter2hunter2")
+	var requested string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Path
+		assert.Equal(t, "true", r.Header.Get("Add-Padding"))
+		fmt.Fprintf(w, "%s:42\r\n%s:0\r\n", hash[5:], strings.Repeat("0", 35))
+	}))
+	defer server.Close()
+
+	provider := NewHIBPRangeProvider(server.URL+"/range", 0)
+	suffixes, err := provider.Range(hash[:5])
+	require.NoError(t, err)
+
+	assert.Equal(t, "/range/"+hash[:5], requested, "only the prefix leaves the process")
+	assert.Equal(t, map[string]int{hash[5:]: 42}, suffixes)
+}
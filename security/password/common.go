@@ -0,0 +1,29 @@
+package password
+
+import (
+	_ "embed"
+	"strings"
+	"sync"
+)
+
+// common_passwords.txt lists frequently used and breached passwords, one per
+// line, lowercased
+//
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+var commonPasswords = sync.OnceValue(func() map[string]struct{} {
+	lines := strings.Split(commonPasswordList, "\n")
+	set := make(map[string]struct{}, len(lines))
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			set[line] = struct{}{}
+		}
+	}
+	return set
+})
+
+func isCommonPassword(password string) bool {
+	_, ok := commonPasswords()[strings.ToLower(password)]
+	return ok
+}
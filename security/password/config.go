@@ -1,5 +1,105 @@
 package password
 
+import "time"
+
 type Config struct {
+	// Algorithm hashes new passwords: argon2id (default), bcrypt or scrypt.
+	// Hashes from the other algorithms still verify and report NeedsRehash.
+	Algorithm string `mapstructure:"algorithm"`
+
+	// Cost is the bcrypt cost, defaults to 12
 	Cost int `mapstructure:"hash_cost"`
+
+	Policy  PolicyConfig  `mapstructure:"policy"`
+	Breach  BreachConfig  `mapstructure:"breach"`
+	History HistoryConfig `mapstructure:"history"`
+
+	Argon2 Argon2Config `mapstructure:"argon2"`
+	Scrypt ScryptConfig `mapstructure:"scrypt"`
+
+	// Hasher replaces the configured algorithm for new hashes
+	Hasher Hasher `mapstructure:"-"`
+}
+
+// Argon2Config holds argon2id parameters. Memory is in KiB.
+type Argon2Config struct {
+	Memory      uint32 `mapstructure:"memory"`
+	Iterations  uint32 `mapstructure:"iterations"`
+	Parallelism uint8  `mapstructure:"parallelism"`
+	SaltLength  uint32 `mapstructure:"salt_length"`
+	KeyLength   uint32 `mapstructure:"key_length"`
+}
+
+// ScryptConfig holds scrypt parameters. N is the CPU/memory cost and must be
+// a power of two.
+type ScryptConfig struct {
+	N          int `mapstructure:"n"`
+	R          int `mapstructure:"r"`
+	P          int `mapstructure:"p"`
+	SaltLength int `mapstructure:"salt_length"`
+	KeyLength  int `mapstructure:"key_length"`
+}
+
+// PolicyConfig describes acceptable passwords. Zero values disable a check,
+// except the length bounds which default to 8 and 128.
+type PolicyConfig struct {
+	MinLength int `mapstructure:"min_length"`
+	MaxLength int `mapstructure:"max_length"`
+
+	RequireUpper  bool `mapstructure:"require_upper"`
+	RequireLower  bool `mapstructure:"require_lower"`
+	RequireDigit  bool `mapstructure:"require_digit"`
+	RequireSymbol bool `mapstructure:"require_symbol"`
+	// MinClasses requires that many of the four character classes
+	MinClasses int `mapstructure:"min_classes"`
+
+	// MinEntropy is the minimum estimated entropy in bits
+	MinEntropy float64 `mapstructure:"min_entropy"`
+
+	// MaxRepeated limits runs of one character, e.g. 2 rejects "aaa".
+	// MaxSequential limits runs like "abcd" or "4321", e.g. 3 rejects "1234".
+	MaxRepeated   int `mapstructure:"max_repeated"`
+	MaxSequential int `mapstructure:"max_sequential"`
+
+	// RejectCommon rejects passwords from the embedded common-password list
+	RejectCommon bool `mapstructure:"reject_common"`
+	// BannedWords may not appear in passwords, like the username and email
+	// passed in PolicyContext
+	BannedWords []string `mapstructure:"banned_words"`
+}
+
+// BreachConfig rejects passwords found in known breaches. Lookups use
+// k-anonymity: only the first five hex characters of the SHA-1 hash are
+// sent to the provider.
+type BreachConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// Provider is "hibp" (default) for the Pwned Passwords API or "file" for
+	// a local hash file. RangeProvider overrides both.
+	Provider      string        `mapstructure:"provider"`
+	APIURL        string        `mapstructure:"api_url"`
+	File          string        `mapstructure:"file"`
+	Timeout       time.Duration `mapstructure:"timeout"`
+	RangeProvider RangeProvider `mapstructure:"-"`
+
+	// MinCount is how often a password must appear in breaches to be
+	// rejected, defaults to 1
+	MinCount int `mapstructure:"min_count"`
+	// CacheTTL keeps range responses, defaults to one hour. Negative
+	// disables caching.
+	CacheTTL time.Duration `mapstructure:"cache_ttl"`
+	// FailClosed rejects passwords while the provider is unavailable. By
+	// default they are accepted.
+	FailClosed bool `mapstructure:"fail_closed"`
+}
+
+// HistoryConfig tracks password changes per user. Store is required for
+// ChangePassword and PasswordExpiry, e.g. NewGormHistoryStore.
+type HistoryConfig struct {
+	// Remember rejects reuse of the last that many passwords
+	Remember int `mapstructure:"remember"`
+	// MaxAge is how long a password stays valid; zero means forever
+	MaxAge time.Duration `mapstructure:"max_age"`
+
+	Store HistoryStore `mapstructure:"-"`
 }
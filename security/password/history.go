@@ -0,0 +1,94 @@
+package password
+
+import (
+	"errors"
+	"time"
+)
+
+func (m *manager) ChangePassword(userID, password string, context PolicyContext) (string, error) {
+	store := m.config.History.Store
+	if store == nil {
+		return "", ErrHistoryDisabled
+	}
+
+	var policyErr *PolicyError
+	if err := m.ValidatePasswordFor(password, context); err != nil && !errors.As(err, &policyErr) {
+		return "", err
+	}
+
+	if remember := m.config.History.Remember; remember > 0 {
+		reused, err := m.reused(store, userID, password, remember)
+		if err != nil {
+			return "", err
+		}
+		if reused {
+			if policyErr == nil {
+				policyErr = &PolicyError{}
+			}
+			policyErr.Violations = append(policyErr.Violations, Violation{
+				Code:    ViolationPasswordReused,
+				Message: "password must differ from your recent passwords",
+				Limit:   remember,
+			})
+		}
+	}
+
+	if policyErr != nil {
+		return "", policyErr
+	}
+	if m.err != nil {
+		return "", m.err
+	}
+
+	hash, err := m.hasher.Hash(password)
+	if err != nil {
+		return "", err
+	}
+
+	if err := store.Add(&HistoryEntry{UserID: userID, Hash: hash, CreatedAt: time.Now()}); err != nil {
+		return "", err
+	}
+
+	// The newest entry is kept regardless, it dates the current password
+	if err := store.Prune(userID, max(m.config.History.Remember, 1)); err != nil {
+		return "", err
+	}
+
+	return hash, nil
+}
+
+func (m *manager) PasswordExpiry(userID string) (*Expiry, error) {
+	store := m.config.History.Store
+	if store == nil {
+		return nil, ErrHistoryDisabled
+	}
+
+	entries, err := store.Recent(userID, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, ErrNoPasswordHistory
+	}
+
+	return newExpiry(entries[0].CreatedAt, m.config.History.MaxAge, time.Now()), nil
+}
+
+// reused reports whether password matches one of the user's recent hashes
+func (m *manager) reused(store HistoryStore, userID, password string, remember int) (bool, error) {
+	entries, err := store.Recent(userID, remember)
+	if err != nil {
+		return false, err
+	}
+
+	for _, entry := range entries {
+		hasher, ok := m.hashers[hashID(entry.Hash)]
+		if !ok {
+			continue
+		}
+		if hasher.Verify(entry.Hash, password) == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,45 @@
+package password
+
+import "github.com/upnext-fng/fulcrum/database"
+
+type gormHistoryStore struct {
+	db database.DatabaseService
+}
+
+// NewGormHistoryStore stores password history in the password_history
+// table. Migrate HistoryEntry before use.
+func NewGormHistoryStore(db database.DatabaseService) HistoryStore {
+	return &gormHistoryStore{
+		db: db,
+	}
+}
+
+func (s *gormHistoryStore) Add(entry *HistoryEntry) error {
+	return s.db.Connection().Create(entry).Error
+}
+
+func (s *gormHistoryStore) Recent(userID string, limit int) ([]HistoryEntry, error) {
+	var entries []HistoryEntry
+	err := s.db.Connection().
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&entries).Error
+	return entries, err
+}
+
+func (s *gormHistoryStore) Prune(userID string, keep int) error {
+	db := s.db.Connection()
+
+	var kept []uint
+	err := db.Model(&HistoryEntry{}).
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(keep).
+		Pluck("id", &kept).Error
+	if err != nil || len(kept) == 0 {
+		return err
+	}
+
+	return db.Where("user_id = ? AND id NOT IN ?", userID, kept).Delete(&HistoryEntry{}).Error
+}
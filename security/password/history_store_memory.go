@@ -0,0 +1,63 @@
+package password
+
+import (
+	"sort"
+	"sync"
+)
+
+type memoryHistoryStore struct {
+	mu      sync.RWMutex
+	entries map[string][]HistoryEntry
+	nextID  uint
+}
+
+// NewMemoryHistoryStore creates a process-local history store, useful for
+// tests
+func NewMemoryHistoryStore() HistoryStore {
+	return &memoryHistoryStore{
+		entries: make(map[string][]HistoryEntry),
+	}
+}
+
+func (s *memoryHistoryStore) Add(entry *HistoryEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	entry.ID = s.nextID
+	s.entries[entry.UserID] = append(s.entries[entry.UserID], *entry)
+	return nil
+}
+
+func (s *memoryHistoryStore) Recent(userID string, limit int) ([]HistoryEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := newestFirst(s.entries[userID])
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+func (s *memoryHistoryStore) Prune(userID string, keep int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := newestFirst(s.entries[userID])
+	if len(entries) > keep {
+		s.entries[userID] = entries[:keep]
+	}
+	return nil
+}
+
+func newestFirst(entries []HistoryEntry) []HistoryEntry {
+	sorted := append([]HistoryEntry(nil), entries...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].CreatedAt.Equal(sorted[j].CreatedAt) {
+			return sorted[i].ID > sorted[j].ID
+		}
+		return sorted[i].CreatedAt.After(sorted[j].CreatedAt)
+	})
+	return sorted
+}
@@ -0,0 +1,81 @@
+package password
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory_RejectsReuse(t *testing.T) {
+	config := testConfig
+	config.History = HistoryConfig{Remember: 2, Store: NewMemoryHistoryStore()}
+	service := NewManager(config)
+
+	first, err := service.ChangePassword("test-user-123", "first-password", PolicyContext{})
+	require.NoError(t, err)
+	assert.NoError(t, service.VerifyPassword(first, "first-password"))
+
+	_, err = service.ChangePassword("test-user-123", "first-password", PolicyContext{})
+	var policyErr *PolicyError
+	require.ErrorAs(t, err, &policyErr)
+	assert.True(t, policyErr.Has(ViolationPasswordReused))
+
+	_, err = service.ChangePassword("test-user-123", "second-password", PolicyContext{})
+	require.NoError(t, err)
+	_, err = service.ChangePassword("test-user-123", "third-password", PolicyContext{})
+	require.NoError(t, err)
+
+	// The first password has dropped out of the remembered two
+	_, err = service.ChangePassword("test-user-123", "first-password", PolicyContext{})
+	assert.NoError(t, err)
+
+	// History is per user
+	_, err = service.ChangePassword("other-user", "third-password", PolicyContext{})
+	assert.NoError(t, err)
+}
+
+func TestHistory_ReportsPolicyAndReuseTogether(t *testing.T) {
+	config := testConfig
+	config.Policy = PolicyConfig{RequireDigit: true}
+	config.History = HistoryConfig{Remember: 1, Store: NewMemoryHistoryStore()}
+	service := NewManager(config)
+
+	_, err := service.ChangePassword("test-user-123", "firstpassword1", PolicyContext{})
+	require.NoError(t, err)
+
+	config.Policy.RequireSymbol = true
+	strict := NewManager(config)
+	_, err = strict.ChangePassword("test-user-123", "firstpassword1", PolicyContext{})
+	var policyErr *PolicyError
+	require.ErrorAs(t, err, &policyErr)
+	assert.Len(t, policyErr.Violations, 2)
+}
+
+func TestHistory_Expiry(t *testing.T) {
+	store := NewMemoryHistoryStore()
+	config := testConfig
+	config.History = HistoryConfig{MaxAge: 90 * 24 * time.Hour, Store: store}
+	service := NewManager(config)
+
+	_, err := service.PasswordExpiry("test-user-123")
+	assert.ErrorIs(t, err, ErrNoPasswordHistory)
+
+	_, err = service.ChangePassword("test-user-123", "first-password", PolicyContext{})
+	require.NoError(t, err)
+
+	expiry, err := service.PasswordExpiry("test-user-123")
+	require.NoError(t, err)
+	assert.False(t, expiry.Expired)
+	assert.Equal(t, 90, expiry.DaysLeft)
+
+	require.NoError(t, store.Add(&HistoryEntry{UserID: "old-user", Hash: "x", CreatedAt: time.Now().Add(-100 * 24 * time.Hour)}))
+	expiry, err = service.PasswordExpiry("old-user")
+	require.NoError(t, err)
+	assert.True(t, expiry.Expired)
+	assert.Zero(t, expiry.DaysLeft)
+
+	_, err = NewManager(testConfig).ChangePassword("test-user-123", "first-password", PolicyContext{})
+	assert.ErrorIs(t, err, ErrHistoryDisabled)
+}
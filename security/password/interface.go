@@ -4,4 +4,49 @@ type Service interface {
 	HashPassword(password string) (string, error)
 	VerifyPassword(hashedPassword, password string) error
 	ValidatePassword(password string) error
+	// ValidatePasswordFor also rejects passwords containing the user's data.
+	// A failed policy returns a *PolicyError listing every violation.
+	ValidatePasswordFor(password string, context PolicyContext) error
+	// ChangePassword validates a user's new password like ValidatePasswordFor,
+	// rejects recently used ones and records the change. It returns the hash
+	// to store for the user.
+	ChangePassword(userID, password string, context PolicyContext) (string, error)
+	// PasswordExpiry reports when the user's current password expires
+	PasswordExpiry(userID string) (*Expiry, error)
+
+	// BreachCount reports how often a password appears in known breaches
+	BreachCount(password string) (int, error)
+
+	// NeedsRehash reports whether a hash uses another algorithm or outdated
+	// parameters
+	NeedsRehash(hashedPassword string) bool
+	// VerifyAndRehash verifies a password like VerifyPassword. When the hash
+	// is outdated it also returns a fresh hash, which the caller should store.
+	VerifyAndRehash(hashedPassword, password string) (string, error)
+}
+
+// Hasher is one password hashing algorithm. Encoded hashes identify their
+// algorithm, e.g. "$argon2id$..." in PHC format, so a hasher only verifies
+// hashes of its own ID.
+type Hasher interface {
+	ID() string
+	Hash(password string) (string, error)
+	Verify(encoded, password string) error
+	NeedsRehash(encoded string) bool
+}
+
+// RangeProvider looks up breached password hashes by k-anonymity range.
+// Range returns the 35-character uppercase SHA-1 suffixes of all hashes
+// starting with the 5-character prefix, with how often each was seen.
+type RangeProvider interface {
+	Range(prefix string) (map[string]int, error)
+}
+
+// HistoryStore records password hashes per user
+type HistoryStore interface {
+	Add(entry *HistoryEntry) error
+	// Recent returns up to limit entries, newest first
+	Recent(userID string, limit int) ([]HistoryEntry, error)
+	// Prune deletes all but the newest keep entries
+	Prune(userID string, keep int) error
 }
@@ -1,46 +1,139 @@
 package password
 
-import (
-	"errors"
-
-	"golang.org/x/crypto/bcrypt"
-)
+import "fmt"
 
 type manager struct {
-	config Config
+	config  Config
+	policy  *policy
+	hasher  Hasher
+	hashers map[string]Hasher
+	err     error
+
+	breach     *breachChecker
+	breachErr  error
+	failClosed bool
 }
 
 func NewManager(config Config) Service {
-	return &manager{
-		config: config,
+	hashers := map[string]Hasher{
+		AlgorithmArgon2id: NewArgon2idHasher(config.Argon2),
+		AlgorithmBcrypt:   NewBcryptHasher(config.Cost),
+		AlgorithmScrypt:   NewScryptHasher(config.Scrypt),
+	}
+
+	m := &manager{
+		config:  config,
+		policy:  newPolicy(config.Policy),
+		hashers: hashers,
+	}
+
+	if config.Breach.Enabled {
+		m.breach, m.breachErr = newBreachChecker(config.Breach)
+		m.failClosed = config.Breach.FailClosed
+	}
+
+	switch {
+	case config.Hasher != nil:
+		m.hasher = config.Hasher
+		hashers[config.Hasher.ID()] = config.Hasher
+	case config.Algorithm == "":
+		m.hasher = hashers[AlgorithmArgon2id]
+	default:
+		// An unknown algorithm fails hashing rather than construction, so
+		// existing hashes keep verifying
+		m.hasher = hashers[config.Algorithm]
+		if m.hasher == nil {
+			m.err = fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, config.Algorithm)
+		}
 	}
+
+	return m
 }
 
 func (m *manager) HashPassword(password string) (string, error) {
 	if err := m.ValidatePassword(password); err != nil {
 		return "", err
 	}
+	if m.err != nil {
+		return "", m.err
+	}
 
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), m.config.Cost)
-	return string(bytes), err
+	return m.hasher.Hash(password)
 }
 
+// VerifyPassword only enforces the length bounds, so passwords set before a
+// stricter policy keep working
 func (m *manager) VerifyPassword(hashedPassword, password string) error {
-	if err := m.ValidatePassword(password); err != nil {
-		return err
+	if violations := m.policy.checkLength(password); len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+
+	hasher, ok := m.hashers[hashID(hashedPassword)]
+	if !ok {
+		return ErrUnsupportedAlgorithm
+	}
+	return hasher.Verify(hashedPassword, password)
+}
+
+func (m *manager) NeedsRehash(hashedPassword string) bool {
+	if m.err != nil {
+		return false
+	}
+	if hashID(hashedPassword) != m.hasher.ID() {
+		return true
+	}
+	return m.hasher.NeedsRehash(hashedPassword)
+}
+
+func (m *manager) VerifyAndRehash(hashedPassword, password string) (string, error) {
+	if err := m.VerifyPassword(hashedPassword, password); err != nil {
+		return "", err
+	}
+	if !m.NeedsRehash(hashedPassword) {
+		return "", nil
 	}
 
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	return m.hasher.Hash(password)
 }
 
 func (m *manager) ValidatePassword(password string) error {
-	if len(password) < 8 {
-		return errors.New("password must be at least 8 characters long")
+	return m.ValidatePasswordFor(password, PolicyContext{})
+}
+
+func (m *manager) ValidatePasswordFor(password string, context PolicyContext) error {
+	violations := m.policy.check(password, context)
+
+	if m.breachErr != nil {
+		return m.breachErr
 	}
 
-	if len(password) > 128 {
-		return errors.New("password must not exceed 128 characters")
+	// Oversized input is not worth a lookup. Without FailClosed an
+	// unavailable provider lets the password through.
+	if m.breach != nil && len(m.policy.checkLength(password)) == 0 {
+		breached, err := m.breach.breached(password)
+		switch {
+		case err != nil && m.failClosed:
+			return err
+		case breached:
+			violations = append(violations, Violation{
+				Code:    ViolationBreachedPassword,
+				Message: "password has appeared in a data breach, choose another one",
+			})
+		}
 	}
 
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
 	return nil
 }
+
+func (m *manager) BreachCount(password string) (int, error) {
+	if m.breachErr != nil {
+		return 0, m.breachErr
+	}
+	if m.breach == nil {
+		return 0, ErrBreachDisabled
+	}
+	return m.breach.count(password)
+}
@@ -0,0 +1,89 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Cheap parameters keep the tests fast
+var testConfig = Config{
+	Cost:   4,
+	Argon2: Argon2Config{Memory: 1024, Iterations: 1, Parallelism: 1},
+	Scrypt: ScryptConfig{N: 1024, R: 8, P: 1},
+}
+
+func TestPassword_Algorithms(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmArgon2id, AlgorithmBcrypt, AlgorithmScrypt} {
+		t.Run(algorithm, func(t *testing.T) {
+			config := testConfig
+			config.Algorithm = algorithm
+			service := NewManager(config)
+
+			hash, err := service.HashPassword("correct horse battery")
+			require.NoError(t, err)
+			assert.Equal(t, algorithm, hashID(hash))
+
+			assert.NoError(t, service.VerifyPassword(hash, "correct horse battery"))
+			assert.ErrorIs(t, service.VerifyPassword(hash, "wrong horse battery"), ErrMismatchedPassword)
+			assert.False(t, service.NeedsRehash(hash))
+
+			other, err := service.HashPassword("correct horse battery")
+			require.NoError(t, err)
+			assert.NotEqual(t, hash, other, "salted hashes differ")
+		})
+	}
+}
+
+func TestPassword_PHCFormat(t *testing.T) {
+	service := NewManager(testConfig)
+
+	hash, err := service.HashPassword("correct horse battery")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"), hash)
+
+	config := testConfig
+	config.Algorithm = AlgorithmScrypt
+	hash, err = NewManager(config).HashPassword("correct horse battery")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$scrypt$ln=10,r=8,p=1$"), hash)
+
+	assert.ErrorIs(t, service.VerifyPassword("$argon2id$v=19$m=1024$bad", "correct horse battery"), ErrInvalidHash)
+	assert.ErrorIs(t, service.VerifyPassword("plaintext", "correct horse battery"), ErrUnsupportedAlgorithm)
+}
+
+func TestPassword_VerifyAndRehash(t *testing.T) {
+	legacyConfig := testConfig
+	legacyConfig.Algorithm = AlgorithmBcrypt
+	legacy, err := NewManager(legacyConfig).HashPassword("correct horse battery")
+	require.NoError(t, err)
+
+	service := NewManager(testConfig)
+	assert.True(t, service.NeedsRehash(legacy))
+
+	_, err = service.VerifyAndRehash(legacy, "wrong horse battery")
+	assert.ErrorIs(t, err, ErrMismatchedPassword)
+
+	upgraded, err := service.VerifyAndRehash(legacy, "correct horse battery")
+	require.NoError(t, err)
+	assert.Equal(t, AlgorithmArgon2id, hashID(upgraded))
+
+	rehashed, err := service.VerifyAndRehash(upgraded, "correct horse battery")
+	require.NoError(t, err)
+	assert.Empty(t, rehashed)
+
+	// Stronger parameters outdate existing hashes of the same algorithm
+	stronger := testConfig
+	stronger.Argon2.Iterations = 2
+	assert.True(t, NewManager(stronger).NeedsRehash(upgraded))
+}
+
+func TestPassword_DefaultBcryptCost(t *testing.T) {
+	service := NewManager(Config{Algorithm: AlgorithmBcrypt})
+	assert.Equal(t, defaultBcryptCost, service.(*manager).hasher.(*bcryptHasher).cost)
+
+	_, err := NewManager(Config{Algorithm: "md5"}).HashPassword("correct horse battery")
+	assert.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+}
@@ -0,0 +1,93 @@
+package password
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// phcHash is a PHC string: $id$v=19$k=v,k=v$salt$hash. Salt and hash are
+// unpadded standard base64.
+type phcHash struct {
+	id      string
+	version int
+	params  map[string]string
+	salt    []byte
+	hash    []byte
+}
+
+func (h *phcHash) encode(params string) string {
+	var b strings.Builder
+	b.WriteString("$" + h.id)
+	if h.version != 0 {
+		b.WriteString("$v=" + strconv.Itoa(h.version))
+	}
+	b.WriteString("$" + params)
+	b.WriteString("$" + base64.RawStdEncoding.EncodeToString(h.salt))
+	b.WriteString("$" + base64.RawStdEncoding.EncodeToString(h.hash))
+	return b.String()
+}
+
+func parsePHC(encoded string) (*phcHash, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) < 5 || parts[0] != "" {
+		return nil, ErrInvalidHash
+	}
+
+	h := &phcHash{id: parts[1], params: make(map[string]string)}
+	fields := parts[2:]
+
+	if version, ok := strings.CutPrefix(fields[0], "v="); ok {
+		v, err := strconv.Atoi(version)
+		if err != nil {
+			return nil, ErrInvalidHash
+		}
+		h.version = v
+		fields = fields[1:]
+	}
+	if len(fields) != 3 {
+		return nil, ErrInvalidHash
+	}
+
+	for _, param := range strings.Split(fields[0], ",") {
+		key, value, ok := strings.Cut(param, "=")
+		if !ok {
+			return nil, ErrInvalidHash
+		}
+		h.params[key] = value
+	}
+
+	var err error
+	if h.salt, err = base64.RawStdEncoding.DecodeString(fields[1]); err != nil {
+		return nil, ErrInvalidHash
+	}
+	if h.hash, err = base64.RawStdEncoding.DecodeString(fields[2]); err != nil || len(h.hash) == 0 {
+		return nil, ErrInvalidHash
+	}
+
+	return h, nil
+}
+
+// uintParam reads a numeric parameter no larger than bits
+func (h *phcHash) uintParam(key string, bits int) (uint64, error) {
+	value, err := strconv.ParseUint(h.params[key], 10, bits)
+	if err != nil {
+		return 0, fmt.Errorf("%w: parameter %s", ErrInvalidHash, key)
+	}
+	return value, nil
+}
+
+// hashID returns the algorithm of an encoded hash. bcrypt predates PHC and
+// keeps its own $2a$/$2b$/$2y$ prefixes.
+func hashID(encoded string) string {
+	if strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$") {
+		return AlgorithmBcrypt
+	}
+
+	parts := strings.SplitN(encoded, "$", 3)
+	if len(parts) < 3 || parts[0] != "" {
+		return ""
+	}
+	return parts[1]
+}
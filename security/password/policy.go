@@ -0,0 +1,238 @@
+package password
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	defaultMinLength = 8
+	defaultMaxLength = 128
+
+	// Banned words shorter than this would reject too many passwords
+	minBannedWordLength = 3
+)
+
+type policy struct {
+	config PolicyConfig
+}
+
+func newPolicy(config PolicyConfig) *policy {
+	if config.MinLength <= 0 {
+		config.MinLength = defaultMinLength
+	}
+	if config.MaxLength <= 0 {
+		config.MaxLength = defaultMaxLength
+	}
+
+	return &policy{
+		config: config,
+	}
+}
+
+// checkLength enforces only the length bounds, which also protect hashing
+// from oversized input
+func (p *policy) checkLength(password string) []Violation {
+	var violations []Violation
+
+	length := utf8.RuneCountInString(password)
+	if length < p.config.MinLength {
+		violations = append(violations, Violation{
+			Code:    ViolationTooShort,
+			Message: fmt.Sprintf("password must be at least %d characters long", p.config.MinLength),
+			Limit:   p.config.MinLength,
+		})
+	}
+	if length > p.config.MaxLength {
+		violations = append(violations, Violation{
+			Code:    ViolationTooLong,
+			Message: fmt.Sprintf("password must not exceed %d characters", p.config.MaxLength),
+			Limit:   p.config.MaxLength,
+		})
+	}
+
+	return violations
+}
+
+func (p *policy) check(password string, context PolicyContext) []Violation {
+	violations := p.checkLength(password)
+	config := p.config
+
+	classes := characterClasses(password)
+	if config.RequireUpper && !classes.upper {
+		violations = append(violations, Violation{Code: ViolationMissingUpper, Message: "password must contain an uppercase letter"})
+	}
+	if config.RequireLower && !classes.lower {
+		violations = append(violations, Violation{Code: ViolationMissingLower, Message: "password must contain a lowercase letter"})
+	}
+	if config.RequireDigit && !classes.digit {
+		violations = append(violations, Violation{Code: ViolationMissingDigit, Message: "password must contain a digit"})
+	}
+	if config.RequireSymbol && !classes.symbol {
+		violations = append(violations, Violation{Code: ViolationMissingSymbol, Message: "password must contain a symbol"})
+	}
+	if config.MinClasses > 0 && classes.count() < config.MinClasses {
+		violations = append(violations, Violation{
+			Code:    ViolationTooFewClasses,
+			Message: fmt.Sprintf("password must mix at least %d of uppercase, lowercase, digits and symbols", config.MinClasses),
+			Limit:   config.MinClasses,
+		})
+	}
+
+	if config.MinEntropy > 0 && entropy(password, classes) < config.MinEntropy {
+		violations = append(violations, Violation{
+			Code:    ViolationLowEntropy,
+			Message: "password is too predictable, make it longer or more varied",
+			Limit:   int(math.Ceil(config.MinEntropy)),
+		})
+	}
+
+	if config.MaxRepeated > 0 && longestRepeat(password) > config.MaxRepeated {
+		violations = append(violations, Violation{
+			Code:    ViolationRepeatedCharacters,
+			Message: fmt.Sprintf("password must not repeat a character more than %d times in a row", config.MaxRepeated),
+			Limit:   config.MaxRepeated,
+		})
+	}
+	if config.MaxSequential > 0 && longestSequence(password) > config.MaxSequential {
+		violations = append(violations, Violation{
+			Code:    ViolationSequentialChars,
+			Message: fmt.Sprintf("password must not contain sequences longer than %d characters, like abcd or 1234", config.MaxSequential),
+			Limit:   config.MaxSequential,
+		})
+	}
+
+	if config.RejectCommon && isCommonPassword(password) {
+		violations = append(violations, Violation{Code: ViolationCommonPassword, Message: "password is too common"})
+	}
+
+	if p.containsBannedWord(password, context) {
+		violations = append(violations, Violation{Code: ViolationContainsUserInput, Message: "password must not contain your username, email or other personal information"})
+	}
+
+	return violations
+}
+
+func (p *policy) containsBannedWord(password string, context PolicyContext) bool {
+	words := append([]string{context.Username, context.Email}, context.Extra...)
+	words = append(words, p.config.BannedWords...)
+
+	// The local part of an email is as guessable as the address
+	if local, _, ok := strings.Cut(context.Email, "@"); ok {
+		words = append(words, local)
+	}
+
+	lowered := strings.ToLower(password)
+	for _, word := range words {
+		if utf8.RuneCountInString(word) < minBannedWordLength {
+			continue
+		}
+		if strings.Contains(lowered, strings.ToLower(word)) {
+			return true
+		}
+	}
+	return false
+}
+
+type classSet struct {
+	upper, lower, digit, symbol, other bool
+}
+
+func (s classSet) count() int {
+	count := 0
+	for _, present := range []bool{s.upper, s.lower, s.digit, s.symbol || s.other} {
+		if present {
+			count++
+		}
+	}
+	return count
+}
+
+func characterClasses(password string) classSet {
+	var set classSet
+	for _, r := range password {
+		switch {
+		case r < utf8.RuneSelf && unicode.IsUpper(r):
+			set.upper = true
+		case r < utf8.RuneSelf && unicode.IsLower(r):
+			set.lower = true
+		case r < utf8.RuneSelf && unicode.IsDigit(r):
+			set.digit = true
+		case r < utf8.RuneSelf:
+			set.symbol = true
+		default:
+			set.other = true
+		}
+	}
+	return set
+}
+
+// entropy estimates bits as length times log2 of the character pool. It
+// overrates patterned passwords, which the other checks catch.
+func entropy(password string, classes classSet) float64 {
+	pool := 0
+	if classes.upper {
+		pool += 26
+	}
+	if classes.lower {
+		pool += 26
+	}
+	if classes.digit {
+		pool += 10
+	}
+	if classes.symbol {
+		pool += 33
+	}
+	if classes.other {
+		pool += 100
+	}
+	if pool == 0 {
+		return 0
+	}
+
+	return float64(utf8.RuneCountInString(password)) * math.Log2(float64(pool))
+}
+
+func longestRepeat(password string) int {
+	longest, run := 0, 0
+	var previous rune = -1
+	for _, r := range password {
+		if r == previous {
+			run++
+		} else {
+			run = 1
+		}
+		previous = r
+		longest = max(longest, run)
+	}
+	return longest
+}
+
+// longestSequence finds the longest ascending or descending run of letters
+// or digits, ignoring case
+func longestSequence(password string) int {
+	longest, run, step := 0, 0, 0
+	var previous rune = -1
+	for _, r := range strings.ToLower(password) {
+		sequential := r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r))
+		diff := int(r - previous)
+
+		switch {
+		case !sequential:
+			run, step = 0, 0
+		case run > 0 && step != 0 && diff == step:
+			run++
+		case run > 0 && (diff == 1 || diff == -1):
+			run, step = 2, diff
+		default:
+			run, step = 1, 0
+		}
+
+		previous = r
+		longest = max(longest, run)
+	}
+	return longest
+}
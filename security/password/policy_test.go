@@ -0,0 +1,99 @@
+package password
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func policyViolations(t *testing.T, err error) *PolicyError {
+	t.Helper()
+
+	require.ErrorIs(t, err, ErrPolicyViolation)
+	var policyErr *PolicyError
+	require.ErrorAs(t, err, &policyErr)
+	return policyErr
+}
+
+func TestPolicy_DefaultsOnlyCheckLength(t *testing.T) {
+	service := NewManager(Config{})
+
+	assert.NoError(t, service.ValidatePassword("aaaaaaaa"))
+
+	err := policyViolations(t, service.ValidatePassword("short"))
+	assert.Equal(t, "password must be at least 8 characters long", err.Error())
+	assert.True(t, err.Has(ViolationTooShort))
+}
+
+func TestPolicy_ReportsAllViolations(t *testing.T) {
+	service := NewManager(Config{Policy: PolicyConfig{
+		MinLength:     10,
+		RequireUpper:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+		MinEntropy:    60,
+		MaxRepeated:   2,
+		MaxSequential: 3,
+	}})
+
+	err := policyViolations(t, service.ValidatePassword("aaabcdx"))
+	for _, code := range []string{
+		ViolationTooShort,
+		ViolationMissingUpper,
+		ViolationMissingDigit,
+		ViolationMissingSymbol,
+		ViolationLowEntropy,
+		ViolationRepeatedCharacters,
+		ViolationSequentialChars,
+	} {
+		assert.True(t, err.Has(code), code)
+	}
+
+	assert.NoError(t, service.ValidatePassword("Tr0ub4dor&3-horse"))
+}
+
+func TestPolicy_CharacterClasses(t *testing.T) {
+	service := NewManager(Config{Policy: PolicyConfig{MinClasses: 3}})
+
+	err := policyViolations(t, service.ValidatePassword("lowercaseonly"))
+	assert.True(t, err.Has(ViolationTooFewClasses))
+	assert.NoError(t, service.ValidatePassword("Lowercase1only"))
+}
+
+func TestPolicy_Sequences(t *testing.T) {
+	assert.Equal(t, 4, longestSequence("xx1234yy"))
+	assert.Equal(t, 4, longestSequence("DCBA"))
+	assert.Equal(t, 3, longestSequence("abcba"))
+	assert.Equal(t, 1, longestSequence("a-b-c"))
+	assert.Equal(t, 3, longestRepeat("abbbc"))
+}
+
+func TestPolicy_CommonAndUserInput(t *testing.T) {
+	service := NewManager(Config{Policy: PolicyConfig{
+		RejectCommon: true,
+		BannedWords:  []string{"fulcrum"},
+	}})
+
+	err := policyViolations(t, service.ValidatePassword("Password123"))
+	assert.True(t, err.Has(ViolationCommonPassword))
+
+	context := PolicyContext{Username: "jdoe", Email: "jane.doe@example.com"}
+	err = policyViolations(t, service.ValidatePasswordFor("my-JDOE-secret", context))
+	assert.True(t, err.Has(ViolationContainsUserInput))
+	err = policyViolations(t, service.ValidatePasswordFor("jane.doe-rocks", context))
+	assert.True(t, err.Has(ViolationContainsUserInput))
+	err = policyViolations(t, service.ValidatePassword("i-love-fulcrum"))
+	assert.True(t, err.Has(ViolationContainsUserInput))
+
+	assert.NoError(t, service.ValidatePasswordFor("correct horse battery", context))
+}
+
+func TestPolicy_VerifyIgnoresStricterPolicy(t *testing.T) {
+	legacy, err := NewManager(testConfig).HashPassword("password")
+	require.NoError(t, err)
+
+	strict := testConfig
+	strict.Policy = PolicyConfig{RejectCommon: true, RequireDigit: true}
+	assert.NoError(t, NewManager(strict).VerifyPassword(legacy, "password"))
+}
@@ -0,0 +1,126 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"math/bits"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+var defaultScryptConfig = ScryptConfig{
+	N:          1 << 15,
+	R:          8,
+	P:          1,
+	SaltLength: 16,
+	KeyLength:  32,
+}
+
+type scryptHasher struct {
+	config ScryptConfig
+}
+
+func NewScryptHasher(config ScryptConfig) Hasher {
+	if config.N == 0 {
+		config.N = defaultScryptConfig.N
+	}
+	if config.R == 0 {
+		config.R = defaultScryptConfig.R
+	}
+	if config.P == 0 {
+		config.P = defaultScryptConfig.P
+	}
+	if config.SaltLength == 0 {
+		config.SaltLength = defaultScryptConfig.SaltLength
+	}
+	if config.KeyLength == 0 {
+		config.KeyLength = defaultScryptConfig.KeyLength
+	}
+
+	return &scryptHasher{
+		config: config,
+	}
+}
+
+func (h *scryptHasher) ID() string {
+	return AlgorithmScrypt
+}
+
+func (h *scryptHasher) Hash(password string) (string, error) {
+	c := h.config
+	if c.N <= 1 || c.N&(c.N-1) != 0 {
+		return "", fmt.Errorf("scrypt N must be a power of two, got %d", c.N)
+	}
+
+	salt := make([]byte, c.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key, err := scrypt.Key([]byte(password), salt, c.N, c.R, c.P, c.KeyLength)
+	if err != nil {
+		return "", err
+	}
+
+	// The PHC scrypt format stores log2(N) as ln
+	encoded := &phcHash{id: AlgorithmScrypt, salt: salt, hash: key}
+	return encoded.encode(fmt.Sprintf("ln=%d,r=%d,p=%d", bits.TrailingZeros(uint(c.N)), c.R, c.P)), nil
+}
+
+func (h *scryptHasher) Verify(encoded, password string) error {
+	parsed, params, err := h.parse(encoded)
+	if err != nil {
+		return err
+	}
+
+	key, err := scrypt.Key([]byte(password), parsed.salt, params.N, params.R, params.P, len(parsed.hash))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidHash, err)
+	}
+	if subtle.ConstantTimeCompare(key, parsed.hash) != 1 {
+		return ErrMismatchedPassword
+	}
+	return nil
+}
+
+func (h *scryptHasher) NeedsRehash(encoded string) bool {
+	parsed, params, err := h.parse(encoded)
+	if err != nil {
+		return true
+	}
+
+	return params.N != h.config.N ||
+		params.R != h.config.R ||
+		params.P != h.config.P ||
+		len(parsed.salt) != h.config.SaltLength ||
+		len(parsed.hash) != h.config.KeyLength
+}
+
+func (h *scryptHasher) parse(encoded string) (*phcHash, ScryptConfig, error) {
+	parsed, err := parsePHC(encoded)
+	if err != nil {
+		return nil, ScryptConfig{}, err
+	}
+	if parsed.id != AlgorithmScrypt {
+		return nil, ScryptConfig{}, ErrUnsupportedAlgorithm
+	}
+
+	ln, err := parsed.uintParam("ln", 8)
+	if err != nil {
+		return nil, ScryptConfig{}, err
+	}
+	r, err := parsed.uintParam("r", 16)
+	if err != nil {
+		return nil, ScryptConfig{}, err
+	}
+	p, err := parsed.uintParam("p", 16)
+	if err != nil {
+		return nil, ScryptConfig{}, err
+	}
+	if ln == 0 || ln > 32 {
+		return nil, ScryptConfig{}, ErrInvalidHash
+	}
+
+	return parsed, ScryptConfig{N: 1 << ln, R: int(r), P: int(p)}, nil
+}
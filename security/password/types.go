@@ -1,5 +1,133 @@
 package password
 
+import (
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
 type PasswordResult struct {
 	Hash string `json:"hash"`
 }
+
+// Algorithm IDs as they appear in encoded hashes
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmScrypt   = "scrypt"
+)
+
+var (
+	// ErrMismatchedPassword is returned for a wrong password. It is the bcrypt
+	// error, so existing comparisons keep working.
+	ErrMismatchedPassword = bcrypt.ErrMismatchedHashAndPassword
+
+	ErrInvalidHash          = errors.New("invalid password hash")
+	ErrUnsupportedAlgorithm = errors.New("unsupported password hash algorithm")
+	ErrPolicyViolation      = errors.New("password violates policy")
+	ErrBreachCheckFailed    = errors.New("breached password check failed")
+	ErrBreachDisabled       = errors.New("breached password check is disabled")
+	ErrHistoryDisabled      = errors.New("password history is not configured")
+	ErrNoPasswordHistory    = errors.New("no password history for user")
+)
+
+// PolicyContext carries user data a password must not contain
+type PolicyContext struct {
+	Username string
+	Email    string
+	// Extra holds more banned words, such as the user's name
+	Extra []string
+}
+
+// Violation codes
+const (
+	ViolationTooShort           = "too_short"
+	ViolationTooLong            = "too_long"
+	ViolationMissingUpper       = "missing_upper"
+	ViolationMissingLower       = "missing_lower"
+	ViolationMissingDigit       = "missing_digit"
+	ViolationMissingSymbol      = "missing_symbol"
+	ViolationTooFewClasses      = "too_few_classes"
+	ViolationLowEntropy         = "low_entropy"
+	ViolationRepeatedCharacters = "repeated_characters"
+	ViolationSequentialChars    = "sequential_characters"
+	ViolationCommonPassword     = "common_password"
+	ViolationContainsUserInput  = "contains_user_input"
+	ViolationBreachedPassword   = "breached_password"
+	ViolationPasswordReused     = "password_reused"
+)
+
+// Violation is one failed policy rule. Limit holds the configured bound
+// where the rule has one.
+type Violation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Limit   int    `json:"limit,omitempty"`
+}
+
+// PolicyError lists every rule a password failed
+type PolicyError struct {
+	Violations []Violation `json:"violations"`
+}
+
+func (e *PolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e *PolicyError) Is(target error) bool {
+	return target == ErrPolicyViolation
+}
+
+// Has reports whether the password failed the rule with code
+func (e *PolicyError) Has(code string) bool {
+	for _, violation := range e.Violations {
+		if violation.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// HistoryEntry is one password a user has had
+type HistoryEntry struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    string    `json:"user_id" gorm:"index;size:255;not null"`
+	Hash      string    `json:"-" gorm:"size:255;not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+func (HistoryEntry) TableName() string {
+	return "password_history"
+}
+
+// Expiry describes when a user's password expires. Passwords without a
+// maximum age have a zero ExpiresAt and never expire.
+type Expiry struct {
+	ChangedAt time.Time `json:"changed_at"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	Expired   bool      `json:"expired"`
+	// DaysLeft rounds up, so a password expiring in an hour has one day left
+	DaysLeft int `json:"days_left"`
+}
+
+func newExpiry(changedAt time.Time, maxAge time.Duration, now time.Time) *Expiry {
+	expiry := &Expiry{ChangedAt: changedAt}
+	if maxAge <= 0 {
+		return expiry
+	}
+
+	expiry.ExpiresAt = changedAt.Add(maxAge)
+	remaining := expiry.ExpiresAt.Sub(now)
+	expiry.Expired = remaining <= 0
+	if !expiry.Expired {
+		expiry.DaysLeft = int(math.Ceil(remaining.Hours() / 24))
+	}
+	return expiry
+}
@@ -1,5 +1,11 @@
 package security
 
-func NewSecurityService(config Config) SecurityService {
-	return NewManager(config)
+import "github.com/upnext-fng/fulcrum/security/jwt"
+
+// NewSecurityService creates the facade around jwtService, which should be
+// the one built from config.JWT and shared with every other service issuing
+// or revoking tokens, so that they use the same revocation and refresh token
+// stores
+func NewSecurityService(config Config, jwtService jwt.Service) SecurityService {
+	return newManager(config, jwtService)
 }
@@ -0,0 +1,12 @@
+package session
+
+import "time"
+
+type Config struct {
+	// TTL ends a session this long after it was created; zero means never
+	TTL time.Duration `mapstructure:"ttl"`
+	// IdleTimeout ends a session that has not been seen for this long
+	IdleTimeout time.Duration `mapstructure:"idle_timeout"`
+	// TouchInterval throttles last-seen updates, defaults to one minute
+	TouchInterval time.Duration `mapstructure:"touch_interval"`
+}
@@ -0,0 +1,5 @@
+package session
+
+import "go.uber.org/fx"
+
+var Module = fx.Provide(NewService)
@@ -0,0 +1,35 @@
+package session
+
+import (
+	"time"
+
+	"github.com/upnext-fng/fulcrum/security/jwt"
+)
+
+type Service interface {
+	Create(request CreateRequest) (*Session, error)
+	Get(sessionID string) (*Session, error)
+	LinkTokens(sessionID string, pair *jwt.TokenPair) error
+
+	// ValidateSession checks that the session is active and records activity.
+	// It satisfies middleware.SessionValidator.
+	ValidateSession(sessionID string, ipAddress string) error
+
+	// ListActive lists the user's signed-in devices
+	ListActive(userID string) ([]Session, error)
+
+	End(sessionID string) error
+	// EndAll logs the user out everywhere, optionally keeping the current session
+	EndAll(userID string, exceptSessionID string) error
+}
+
+type Store interface {
+	Create(session *Session) error
+	Find(sessionID string) (*Session, error)
+	ListActive(userID string, now time.Time) ([]Session, error)
+	Touch(sessionID string, lastSeenAt time.Time, ipAddress string) error
+	End(sessionID string, endedAt time.Time) error
+	EndAll(userID string, exceptSessionID string, endedAt time.Time) ([]string, error)
+	AddTokens(tokens []SessionToken) error
+	TokenIDs(sessionID string) ([]string, error)
+}
@@ -0,0 +1,186 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/upnext-fng/fulcrum/security/jwt"
+)
+
+const defaultTouchInterval = time.Minute
+
+type manager struct {
+	config  Config
+	store   Store
+	revoker jwt.TokenRevoker
+}
+
+// NewManager creates a session service. When revoker is set, ending a session
+// also revokes its tokens so services that do not check sessions reject them.
+func NewManager(config Config, store Store, revoker jwt.TokenRevoker) Service {
+	if config.TouchInterval <= 0 {
+		config.TouchInterval = defaultTouchInterval
+	}
+
+	return &manager{
+		config:  config,
+		store:   store,
+		revoker: revoker,
+	}
+}
+
+func (m *manager) Create(request CreateRequest) (*Session, error) {
+	if request.UserID == "" {
+		return nil, ErrInvalidUserID
+	}
+
+	now := time.Now()
+	session := &Session{
+		ID:         newSessionID(),
+		UserID:     request.UserID,
+		DeviceID:   request.DeviceID,
+		IPAddress:  request.IPAddress,
+		UserAgent:  request.UserAgent,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+	if m.config.TTL > 0 {
+		expiresAt := now.Add(m.config.TTL)
+		session.ExpiresAt = &expiresAt
+	}
+
+	if err := m.store.Create(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (m *manager) Get(sessionID string) (*Session, error) {
+	return m.store.Find(sessionID)
+}
+
+// LinkTokens records the tokens of pair as issued for the session
+func (m *manager) LinkTokens(sessionID string, pair *jwt.TokenPair) error {
+	now := time.Now()
+
+	var tokens []SessionToken
+	for _, token := range []*jwt.SignedToken{pair.AccessToken, pair.RefreshToken} {
+		if token == nil || token.ID() == "" {
+			continue
+		}
+
+		tokenType := string(jwt.AccessTokenType)
+		if token.IsRefreshToken() {
+			tokenType = string(jwt.RefreshTokenType)
+		}
+
+		tokens = append(tokens, SessionToken{
+			TokenID:   token.ID(),
+			SessionID: sessionID,
+			TokenType: tokenType,
+			ExpiresAt: token.ExpiresAt,
+			CreatedAt: now,
+		})
+	}
+
+	if len(tokens) == 0 {
+		return nil
+	}
+	return m.store.AddTokens(tokens)
+}
+
+func (m *manager) ValidateSession(sessionID string, ipAddress string) error {
+	session, err := m.store.Find(sessionID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if session.EndedAt != nil {
+		return ErrSessionEnded
+	}
+	if !session.IsActiveAt(now) || m.isIdle(session, now) {
+		return ErrSessionExpired
+	}
+
+	if now.Sub(session.LastSeenAt) >= m.config.TouchInterval {
+		if err := m.store.Touch(sessionID, now, ipAddress); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *manager) ListActive(userID string) ([]Session, error) {
+	now := time.Now()
+
+	sessions, err := m.store.ListActive(userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	active := sessions[:0]
+	for _, session := range sessions {
+		if !m.isIdle(&session, now) {
+			active = append(active, session)
+		}
+	}
+	return active, nil
+}
+
+func (m *manager) End(sessionID string) error {
+	if err := m.store.End(sessionID, time.Now()); err != nil {
+		return err
+	}
+	return m.revoke(sessionID)
+}
+
+func (m *manager) EndAll(userID string, exceptSessionID string) error {
+	ended, err := m.store.EndAll(userID, exceptSessionID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, sessionID := range ended {
+		if err := m.revoke(sessionID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *manager) revoke(sessionID string) error {
+	if m.revoker == nil {
+		return nil
+	}
+
+	if err := m.revoker.RevokeSession(sessionID); err != nil {
+		return err
+	}
+
+	// Linked tokens may predate the session_id claim
+	tokenIDs, err := m.store.TokenIDs(sessionID)
+	if err != nil {
+		return err
+	}
+	for _, tokenID := range tokenIDs {
+		if err := m.revoker.Revoke(tokenID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *manager) isIdle(session *Session, now time.Time) bool {
+	return m.config.IdleTimeout > 0 && now.Sub(session.LastSeenAt) > m.config.IdleTimeout
+}
+
+func newSessionID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,93 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/upnext-fng/fulcrum/security/jwt"
+)
+
+func newTestService(t *testing.T, config Config) (Service, jwt.Service) {
+	t.Helper()
+
+	jwtService := jwt.NewJWTService(jwt.Config{
+		Secret:          "test-secret-key-123",
+		AccessTokenTTL:  time.Hour,
+		RefreshTokenTTL: time.Hour * 24,
+	})
+	return NewManager(config, NewMemoryStore(), jwtService), jwtService
+}
+
+func TestSession_Lifecycle(t *testing.T) {
+	service, jwtService := newTestService(t, Config{})
+
+	session, err := service.Create(CreateRequest{
+		UserID:    "test-user-123",
+		DeviceID:  "laptop",
+		IPAddress: "10.0.0.1",
+		UserAgent: "Mozilla/5.0",
+	})
+	require.NoError(t, err)
+	require.NoError(t, service.ValidateSession(session.ID, "10.0.0.1"))
+
+	pair, err := jwtService.GenerateTokenPair(jwt.Claims{UserID: "test-user-123", SessionID: session.ID}, jwt.WithRefreshToken())
+	require.NoError(t, err)
+	require.NoError(t, service.LinkTokens(session.ID, pair))
+
+	require.NoError(t, service.End(session.ID))
+
+	assert.ErrorIs(t, service.ValidateSession(session.ID, "10.0.0.1"), ErrSessionEnded)
+	_, err = jwtService.ValidateToken(pair.AccessToken.Token)
+	assert.ErrorIs(t, err, jwt.ErrTokenRevoked)
+	_, err = jwtService.RefreshTokenPair(pair.RefreshToken.Token)
+	assert.ErrorIs(t, err, jwt.ErrTokenRevoked)
+}
+
+func TestSession_ListAndEndAll(t *testing.T) {
+	service, _ := newTestService(t, Config{})
+
+	laptop, err := service.Create(CreateRequest{UserID: "test-user-123", DeviceID: "laptop"})
+	require.NoError(t, err)
+	phone, err := service.Create(CreateRequest{UserID: "test-user-123", DeviceID: "phone"})
+	require.NoError(t, err)
+	_, err = service.Create(CreateRequest{UserID: "other-user", DeviceID: "tablet"})
+	require.NoError(t, err)
+
+	devices, err := service.ListActive("test-user-123")
+	require.NoError(t, err)
+	assert.Len(t, devices, 2)
+
+	// Log out everywhere except the current device
+	require.NoError(t, service.EndAll("test-user-123", laptop.ID))
+
+	devices, err = service.ListActive("test-user-123")
+	require.NoError(t, err)
+	require.Len(t, devices, 1)
+	assert.Equal(t, laptop.ID, devices[0].ID)
+	assert.ErrorIs(t, service.ValidateSession(phone.ID, ""), ErrSessionEnded)
+
+	other, err := service.ListActive("other-user")
+	require.NoError(t, err)
+	assert.Len(t, other, 1)
+}
+
+func TestSession_Timeouts(t *testing.T) {
+	store := NewMemoryStore()
+	service := NewManager(Config{IdleTimeout: time.Minute}, store, nil)
+
+	session, err := service.Create(CreateRequest{UserID: "test-user-123"})
+	require.NoError(t, err)
+
+	require.NoError(t, store.Touch(session.ID, time.Now().Add(-time.Hour), ""))
+	assert.ErrorIs(t, service.ValidateSession(session.ID, ""), ErrSessionExpired)
+
+	expiring := NewManager(Config{TTL: time.Millisecond}, store, nil)
+	session, err = expiring.Create(CreateRequest{UserID: "test-user-123"})
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	assert.ErrorIs(t, expiring.ValidateSession(session.ID, ""), ErrSessionExpired)
+
+	assert.ErrorIs(t, service.ValidateSession("unknown", ""), ErrSessionNotFound)
+}
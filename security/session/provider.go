@@ -0,0 +1,10 @@
+package session
+
+import (
+	"github.com/upnext-fng/fulcrum/database"
+	"github.com/upnext-fng/fulcrum/security/jwt"
+)
+
+func NewService(config Config, db database.DatabaseService, jwtService jwt.Service) Service {
+	return NewManager(config, NewGormStore(db), jwtService)
+}
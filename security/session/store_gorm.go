@@ -0,0 +1,104 @@
+package session
+
+import (
+	"errors"
+	"time"
+
+	"github.com/upnext-fng/fulcrum/database"
+	"gorm.io/gorm"
+)
+
+type gormStore struct {
+	db database.DatabaseService
+}
+
+// NewGormStore stores sessions in the sessions and session_tokens tables.
+// Migrate Session and SessionToken before use.
+func NewGormStore(db database.DatabaseService) Store {
+	return &gormStore{
+		db: db,
+	}
+}
+
+func (s *gormStore) Create(session *Session) error {
+	return s.db.Connection().Create(session).Error
+}
+
+func (s *gormStore) Find(sessionID string) (*Session, error) {
+	var session Session
+	if err := s.db.Connection().Where("id = ?", sessionID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (s *gormStore) ListActive(userID string, now time.Time) ([]Session, error) {
+	var sessions []Session
+	err := s.db.Connection().
+		Where("user_id = ? AND ended_at IS NULL", userID).
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (s *gormStore) Touch(sessionID string, lastSeenAt time.Time, ipAddress string) error {
+	updates := map[string]interface{}{"last_seen_at": lastSeenAt}
+	if ipAddress != "" {
+		updates["ip_address"] = ipAddress
+	}
+	return s.db.Connection().Model(&Session{}).Where("id = ?", sessionID).Updates(updates).Error
+}
+
+func (s *gormStore) End(sessionID string, endedAt time.Time) error {
+	result := s.db.Connection().Model(&Session{}).
+		Where("id = ? AND ended_at IS NULL", sessionID).
+		Update("ended_at", endedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		// Ending an ended session is fine, ending an unknown one is not
+		_, err := s.Find(sessionID)
+		return err
+	}
+	return nil
+}
+
+func (s *gormStore) EndAll(userID string, exceptSessionID string, endedAt time.Time) ([]string, error) {
+	var ended []string
+
+	err := s.db.Connection().Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&Session{}).Where("user_id = ? AND ended_at IS NULL", userID)
+		if exceptSessionID != "" {
+			query = query.Where("id <> ?", exceptSessionID)
+		}
+
+		if err := query.Pluck("id", &ended).Error; err != nil {
+			return err
+		}
+		if len(ended) == 0 {
+			return nil
+		}
+
+		return tx.Model(&Session{}).Where("id IN ?", ended).Update("ended_at", endedAt).Error
+	})
+
+	return ended, err
+}
+
+func (s *gormStore) AddTokens(tokens []SessionToken) error {
+	return s.db.Connection().Create(&tokens).Error
+}
+
+func (s *gormStore) TokenIDs(sessionID string) ([]string, error) {
+	var tokenIDs []string
+	err := s.db.Connection().Model(&SessionToken{}).
+		Where("session_id = ? AND expires_at > ?", sessionID, time.Now()).
+		Pluck("token_id", &tokenIDs).Error
+	return tokenIDs, err
+}
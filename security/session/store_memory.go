@@ -0,0 +1,132 @@
+package session
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryStore struct {
+	mu       sync.RWMutex
+	sessions map[string]*Session
+	tokens   map[string][]SessionToken
+}
+
+// NewMemoryStore creates a process-local store, useful for tests and single
+// instance deployments
+func NewMemoryStore() Store {
+	return &memoryStore{
+		sessions: make(map[string]*Session),
+		tokens:   make(map[string][]SessionToken),
+	}
+}
+
+func (s *memoryStore) Create(session *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := *session
+	s.sessions[session.ID] = &stored
+	return nil
+}
+
+func (s *memoryStore) Find(sessionID string) (*Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.sessions[sessionID]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+
+	found := *session
+	return &found, nil
+}
+
+func (s *memoryStore) ListActive(userID string, now time.Time) ([]Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var sessions []Session
+	for _, session := range s.sessions {
+		if session.UserID == userID && session.IsActiveAt(now) {
+			sessions = append(sessions, *session)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+func (s *memoryStore) Touch(sessionID string, lastSeenAt time.Time, ipAddress string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[sessionID]
+	if !ok {
+		return ErrSessionNotFound
+	}
+
+	session.LastSeenAt = lastSeenAt
+	if ipAddress != "" {
+		session.IPAddress = ipAddress
+	}
+	return nil
+}
+
+func (s *memoryStore) End(sessionID string, endedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[sessionID]
+	if !ok {
+		return ErrSessionNotFound
+	}
+
+	if session.EndedAt == nil {
+		session.EndedAt = &endedAt
+	}
+	return nil
+}
+
+func (s *memoryStore) EndAll(userID string, exceptSessionID string, endedAt time.Time) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ended []string
+	for id, session := range s.sessions {
+		if session.UserID != userID || id == exceptSessionID || session.EndedAt != nil {
+			continue
+		}
+		session.EndedAt = &endedAt
+		ended = append(ended, id)
+	}
+	return ended, nil
+}
+
+func (s *memoryStore) AddTokens(tokens []SessionToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, token := range tokens {
+		s.tokens[token.SessionID] = append(s.tokens[token.SessionID], token)
+	}
+	return nil
+}
+
+func (s *memoryStore) TokenIDs(sessionID string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+
+	var tokenIDs []string
+	for _, token := range s.tokens[sessionID] {
+		if now.Before(token.ExpiresAt) {
+			tokenIDs = append(tokenIDs, token.TokenID)
+		}
+	}
+	return tokenIDs, nil
+}
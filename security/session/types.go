@@ -0,0 +1,61 @@
+package session
+
+import (
+	"errors"
+	"time"
+)
+
+// Session is a signed-in device of a user
+type Session struct {
+	ID         string     `json:"id" gorm:"primaryKey;size:64"`
+	UserID     string     `json:"user_id" gorm:"index;size:255;not null"`
+	DeviceID   string     `json:"device_id,omitempty" gorm:"size:255"`
+	IPAddress  string     `json:"ip_address,omitempty" gorm:"size:45"`
+	UserAgent  string     `json:"user_agent,omitempty" gorm:"size:1000"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	EndedAt    *time.Time `json:"ended_at,omitempty"`
+}
+
+func (Session) TableName() string {
+	return "sessions"
+}
+
+// IsActiveAt reports whether the session is neither ended nor expired at t
+func (s *Session) IsActiveAt(t time.Time) bool {
+	if s.EndedAt != nil {
+		return false
+	}
+	if s.ExpiresAt != nil && !t.Before(*s.ExpiresAt) {
+		return false
+	}
+	return true
+}
+
+// SessionToken links an issued token to the session it was issued for
+type SessionToken struct {
+	TokenID   string    `json:"token_id" gorm:"primaryKey;size:64"`
+	SessionID string    `json:"session_id" gorm:"index;size:64;not null"`
+	TokenType string    `json:"token_type" gorm:"size:16"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (SessionToken) TableName() string {
+	return "session_tokens"
+}
+
+type CreateRequest struct {
+	UserID    string `json:"user_id"`
+	DeviceID  string `json:"device_id,omitempty"`
+	IPAddress string `json:"ip_address,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionEnded    = errors.New("session ended")
+	ErrSessionExpired  = errors.New("session expired")
+	ErrInvalidUserID   = errors.New("invalid user ID")
+)
@@ -101,12 +101,13 @@ type TokenRequest struct {
 
 // TokenResponse represents a strongly-typed token generation response
 type TokenResponse struct {
-	AccessToken  string     `json:"access_token"`
+	AccessToken  string     `json:"access_token,omitempty"`
 	TokenType    string     `json:"token_type"`
 	ExpiresIn    int64      `json:"expires_in"`
 	ExpiresAt    time.Time  `json:"expires_at"`
 	RefreshToken string     `json:"refresh_token,omitempty"`
 	Scope        []string   `json:"scope,omitempty"`
+	CSRFToken    string     `json:"csrf_token,omitempty"`
 }
 
 // ValidationRequest represents token validation parameters